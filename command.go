@@ -0,0 +1,171 @@
+package main
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/ethanefung/minesweeper/engine"
+)
+
+// exCommand is a command typed after ':' in a game.
+type exCommand struct {
+	name string
+	// args completes the arguments of the command
+	args func() []string
+	run  func(g *game, args []string) (tea.Cmd, error)
+}
+
+var exCommands = []exCommand{
+	{"new", modeNames, runNew},
+	{"custom", nil, runCustom},
+	{"seed", nil, runSeed},
+	{"restart", nil, runRestart},
+	{"set", optionNames, runSet},
+	{"w", nil, runWrite},
+	{"q", nil, runQuit},
+	{"q!", nil, runQuit},
+}
+
+func modeNames() []string {
+	return []string{beginner.String(), intermediate.String(), expert.String()}
+}
+
+// findCommand looks a command up by name or by a prefix only one command
+// starts with, so :res runs :restart.
+func findCommand(name string) (exCommand, error) {
+	found := []exCommand{}
+	for _, c := range exCommands {
+		if c.name == name {
+			return c, nil
+		}
+		if strings.HasPrefix(c.name, name) {
+			found = append(found, c)
+		}
+	}
+	if len(found) != 1 {
+		return exCommand{}, errors.New("not a command: " + name)
+	}
+	return found[0], nil
+}
+
+// completions returns what can follow the words typed so far.
+func completions(words []string) []string {
+	if len(words) <= 1 {
+		names := []string{}
+		for _, c := range exCommands {
+			names = append(names, c.name)
+		}
+		return names
+	}
+	c, err := findCommand(words[0])
+	if err != nil || c.args == nil {
+		return nil
+	}
+	return c.args()
+}
+
+// execute runs a command line typed after ':'.
+func (g *game) execute(line string) tea.Cmd {
+	words := strings.Fields(line)
+	if len(words) == 0 {
+		return nil
+	}
+	c, err := findCommand(words[0])
+	if err != nil {
+		g.message = err.Error()
+		return nil
+	}
+	cmd, err := c.run(g, words[1:])
+	if err != nil {
+		g.message = err.Error()
+	}
+	return cmd
+}
+
+func runNew(g *game, args []string) (tea.Cmd, error) {
+	if len(args) != 1 {
+		return nil, errors.New("usage: :new beginner|intermediate|expert")
+	}
+	mode, err := parseGameMode(args[0])
+	if _, ok := presets[mode]; err != nil || !ok {
+		return nil, errors.New("usage: :new beginner|intermediate|expert")
+	}
+	g.setMode(mode, g.rule, g.noGuess, g.practice, g.fixedSeed)
+	return g.restartStopwatch(), nil
+}
+
+func runCustom(g *game, args []string) (tea.Cmd, error) {
+	usage := errors.New("usage: :custom {width} {height} {mines}")
+	if len(args) != 3 {
+		return nil, usage
+	}
+	values := make([]int, len(args))
+	for i, arg := range args {
+		v, err := strconv.Atoi(arg)
+		if err != nil {
+			return nil, usage
+		}
+		values[i] = v
+	}
+	size := boardSize{values[0], values[1], values[2]}
+	if err := engine.Validate(size.config(g.rule, g.noGuess, g.fixedSeed)); err != nil {
+		return nil, err
+	}
+	g.setMode(custom, g.rule, g.noGuess, g.practice, g.fixedSeed)
+	g.setCustom(size)
+	return g.restartStopwatch(), nil
+}
+
+// runSeed starts the board over from a seed, :seed 0 going back to random
+// seeds. Without a seed it shows the current one.
+func runSeed(g *game, args []string) (tea.Cmd, error) {
+	if len(args) == 0 {
+		g.message = "seed: " + strconv.FormatInt(g.board.Config().Seed, 10)
+		return nil, nil
+	}
+	seed, err := strconv.ParseInt(args[0], 10, 64)
+	if len(args) != 1 || err != nil || seed < 0 {
+		return nil, errors.New("usage: :seed {number}")
+	}
+	g.fixedSeed = seed
+	return g.restart(), nil
+}
+
+func runRestart(g *game, args []string) (tea.Cmd, error) {
+	return g.restart(), nil
+}
+
+func runSet(g *game, args []string) (tea.Cmd, error) {
+	if len(args) == 0 {
+		shown := []string{}
+		for _, o := range options {
+			shown = append(shown, g.model.settings.show(o))
+		}
+		g.message = strings.Join(shown, "  ")
+		return nil, nil
+	}
+	shown := []string{}
+	for _, arg := range args {
+		s, err := g.model.settings.set(arg)
+		if err != nil {
+			return nil, err
+		}
+		shown = append(shown, s)
+	}
+	g.message = strings.Join(shown, "  ")
+	return nil, nil
+}
+
+func runWrite(g *game, args []string) (tea.Cmd, error) {
+	if g.board.State() != engine.Won {
+		return nil, errors.New("only a won game can be saved")
+	}
+	g.model.current = g.model.saveMenu
+	return nil, nil
+}
+
+func runQuit(g *game, args []string) (tea.Cmd, error) {
+	return tea.Quit, nil
+}
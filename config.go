@@ -0,0 +1,20 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// configPath returns where a file of the game's configuration lives,
+// creating the directory it goes in.
+func configPath(name string) (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	dir = filepath.Join(dir, "vim-minesweeper")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name), nil
+}
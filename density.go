@@ -0,0 +1,151 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/ethanefung/minesweeper/engine"
+)
+
+// density is how tightly the board is drawn.
+type density struct {
+	name string
+	// cell is how many columns the inside of a cell takes
+	cell int
+	// spaced boards leave a blank line under every row
+	spaced bool
+	// bordered boards draw lines around every cell
+	bordered bool
+}
+
+var densities = []density{
+	{name: "classic", cell: 3, spaced: true},
+	// compact cells are a column wide, too narrow for an emoji flag
+	{name: "compact", cell: 1},
+	{name: "tight", cell: 3},
+	{name: "bordered", cell: 3, bordered: true},
+}
+
+func densityNames() []string {
+	names := []string{}
+	for _, d := range densities {
+		names = append(names, d.name)
+	}
+	return names
+}
+
+func findDensity(name string) (density, bool) {
+	for _, d := range densities {
+		if d.name == name {
+			return d, true
+		}
+	}
+	return density{}, false
+}
+
+// the lines of the bordered board, as box drawing characters and in ASCII
+type gridLines struct {
+	horizontal, vertical            string
+	topLeft, top, topRight          string
+	left, cross, right              string
+	bottomLeft, bottom, bottomRight string
+}
+
+var boxLines = gridLines{
+	horizontal: "─", vertical: "│",
+	topLeft: "┌", top: "┬", topRight: "┐",
+	left: "├", cross: "┼", right: "┤",
+	bottomLeft: "└", bottom: "┴", bottomRight: "┘",
+}
+
+var asciiLines = gridLines{
+	horizontal: "-", vertical: "|",
+	topLeft: "+", top: "+", topRight: "+",
+	left: "+", cross: "+", right: "+",
+	bottomLeft: "+", bottom: "+", bottomRight: "+",
+}
+
+// boardWidth is how many columns the visible part of the board takes,
+// its row numbers included.
+func (g *game) boardWidth() int {
+	width := g.gutterWidth() + g.viewColumns()*g.cellWidth()
+	if g.model.settings.density().bordered {
+		// the line closing the last column
+		width++
+	}
+	return width
+}
+
+// boardLines is how many lines the visible part of the board takes, its
+// column letters included.
+func (g *game) boardLines() int {
+	lines := g.viewRows() * g.rowHeight()
+	if g.model.settings.density().bordered {
+		// the line above the first row
+		lines++
+	}
+	if g.model.settings.columnLetters {
+		lines++
+	}
+	return lines
+}
+
+// viewBoard draws the visible part of the board, ending with a newline.
+func (g *game) viewBoard() string {
+	d := g.model.settings.density()
+	t := g.model.settings.theme()
+	lines := boxLines
+	if g.model.settings.ascii {
+		lines = asciiLines
+	}
+	gutter := strings.Repeat(" ", g.gutterWidth())
+	// rule draws the line above or under a row of bordered cells
+	rule := func(left, cross, right string) string {
+		segments := make([]string, g.viewColumns())
+		for i := range segments {
+			segments[i] = strings.Repeat(lines.horizontal, d.cell)
+		}
+		return gutter + t.gutter.Render(left+strings.Join(segments, cross)+right) + "\n"
+	}
+
+	b := strings.Builder{}
+	b.WriteString(g.columnLetters())
+	if d.bordered {
+		b.WriteString(rule(lines.topLeft, lines.top, lines.topRight))
+	}
+	for y := g.top; y < g.top+g.viewRows(); y++ {
+		b.WriteString(g.rowNumber(y))
+		for x := g.left; x < g.left+g.viewColumns(); x++ {
+			if d.bordered {
+				b.WriteString(t.gutter.Render(lines.vertical))
+			}
+			b.WriteString(g.viewCell(engine.Coord{X: x, Y: y}))
+		}
+		if d.bordered {
+			b.WriteString(t.gutter.Render(lines.vertical))
+		}
+		b.WriteString("\n")
+		switch {
+		case d.bordered && y == g.top+g.viewRows()-1:
+			b.WriteString(rule(lines.bottomLeft, lines.bottom, lines.bottomRight))
+		case d.bordered:
+			b.WriteString(rule(lines.left, lines.cross, lines.right))
+		case d.spaced:
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// viewHeader draws the mines left, the face and the time over the board,
+// the face in the middle of it.
+func (g *game) viewHeader(flags, face, timer string) string {
+	width := g.boardWidth() - g.gutterWidth()
+	space := width - lipgloss.Width(flags) - lipgloss.Width(timer) - lipgloss.Width(face)
+	if space < 2 {
+		// custom boards can be narrower than the header
+		space = 2
+	}
+	left := space / 2
+	return strings.Repeat(" ", g.gutterWidth()) + flags + strings.Repeat(" ", left) + face + strings.Repeat(" ", space-left) + timer
+}
@@ -0,0 +1,436 @@
+// Package engine implements the rules of minesweeper: laying out mines,
+// revealing, chording and flagging cells and deciding when a game is won or
+// lost. It knows nothing about how a board is drawn.
+package engine
+
+import "fmt"
+
+// Mine is the value of a cell holding a mine. Every other cell holds the
+// number of mines next to it.
+const Mine = -1
+
+// the limits of a board's sides
+const (
+	MinSide = 2
+	MaxSide = 99
+)
+
+// GameState is how far a game has got.
+type GameState int64
+
+const (
+	// Pending boards have no mines yet, they are placed by the first reveal
+	Pending GameState = iota
+	Playing
+	Won
+	Lost
+)
+
+// CellState is what a player can see of a cell.
+type CellState int64
+
+const (
+	Hidden CellState = iota
+	Revealed
+	Flagged
+)
+
+// Coord is a cell of a board, counted from 0 at the top left.
+type Coord struct {
+	X, Y int
+}
+
+// Unwrap returns the column and row of c, to pass to the methods of Board.
+func (c Coord) Unwrap() (int, int) { return c.X, c.Y }
+
+// FirstReveal decides what the first reveal of a game is guaranteed not to
+// hit.
+type FirstReveal int64
+
+const (
+	Classic FirstReveal = iota
+	SafeCell
+	SafeOpening
+)
+
+// FirstReveals lists every FirstReveal, in the order players pick them.
+var FirstReveals = []FirstReveal{Classic, SafeCell, SafeOpening}
+
+// String names fr the way scores are saved with it.
+func (fr FirstReveal) String() string {
+	switch fr {
+	case Classic:
+		return "classic"
+	case SafeCell:
+		return "safe cell"
+	case SafeOpening:
+		return "safe opening"
+	default:
+		return "unknown"
+	}
+}
+
+// safeZone returns the cells that must stay clear of mines when the first
+// reveal happens at (x, y).
+func (fr FirstReveal) safeZone(x, y, width, height int) []Coord {
+	switch fr {
+	case SafeCell:
+		return []Coord{{x, y}}
+	case SafeOpening:
+		return append(neighbours(x, y, width, height), Coord{x, y})
+	}
+	return nil
+}
+
+// Config describes the board to build. Boards built from the same Config
+// and revealed first at the same cell have the same layout.
+type Config struct {
+	Width, Height, Mines int
+	Rule                 FirstReveal
+	Seed                 int64
+	// NoGuess boards can be cleared from the first reveal by deduction
+	// alone. They always start with a safe opening, whatever the Rule.
+	NoGuess bool
+}
+
+func (c Config) safeZone(x, y int) []Coord {
+	if c.NoGuess {
+		return SafeOpening.safeZone(x, y, c.Width, c.Height)
+	}
+	return c.Rule.safeZone(x, y, c.Width, c.Height)
+}
+
+// Validate reports whether a board can be laid out from c wherever the first
+// reveal lands.
+func Validate(c Config) error {
+	if c.Width < MinSide || c.Width > MaxSide {
+		return fmt.Errorf("%w: width must be between %d and %d", ErrBoardSize, MinSide, MaxSide)
+	}
+	if c.Height < MinSide || c.Height > MaxSide {
+		return fmt.Errorf("%w: height must be between %d and %d", ErrBoardSize, MinSide, MaxSide)
+	}
+	if c.Mines < 1 {
+		return fmt.Errorf("%w: there must be at least one mine", ErrMineCount)
+	}
+	// the safe zone is largest away from the edges
+	safe := len(c.safeZone(1, 1))
+	if c.Mines > c.Width*c.Height-safe {
+		return ErrTooManyMines
+	}
+	return nil
+}
+
+// Board is a game of minesweeper. Its mines are laid out by the first
+// reveal, and every move made on it can be undone.
+type Board struct {
+	config Config
+	// in each cell if it is a mine the int will be Mine
+	// otherwise each will be the number of mines next to it
+	grid   [][]int
+	states [][]CellState
+	state  GameState
+	flags  int
+	// detonated is the mine that lost the game and first the cell the
+	// mines were laid out around
+	detonated *Coord
+	first     *Coord
+	// history holds the board before each move, future the moves undone
+	history []snapshot
+	future  []snapshot
+}
+
+// snapshot is everything a move can change.
+type snapshot struct {
+	grid      [][]int
+	states    [][]CellState
+	state     GameState
+	flags     int
+	detonated *Coord
+	first     *Coord
+}
+
+func (b *Board) snapshot() snapshot {
+	s := snapshot{
+		grid:      make([][]int, len(b.grid)),
+		states:    make([][]CellState, len(b.states)),
+		state:     b.state,
+		flags:     b.flags,
+		detonated: b.detonated,
+		first:     b.first,
+	}
+	for y := range b.grid {
+		s.grid[y] = append([]int(nil), b.grid[y]...)
+		s.states[y] = append([]CellState(nil), b.states[y]...)
+	}
+	return s
+}
+
+func (b *Board) restore(s snapshot) {
+	b.grid, b.states, b.state, b.flags, b.detonated, b.first = s.grid, s.states, s.state, s.flags, s.detonated, s.first
+}
+
+// record remembers the board as it was before a move changed it. A new move
+// forgets the moves that were undone.
+func (b *Board) record(before snapshot) {
+	b.history = append(b.history, before)
+	b.future = nil
+}
+
+// Batch makes the moves fn makes undo and redo as one.
+func (b *Board) Batch(fn func()) {
+	before := len(b.history)
+	fn()
+	if len(b.history) > before+1 {
+		// the first snapshot is the board before all of them
+		b.history = b.history[:before+1]
+	}
+}
+
+// Undo takes back the last move, even one that lost the game.
+func (b *Board) Undo() error {
+	if len(b.history) == 0 {
+		return ErrNothingToUndo
+	}
+	b.future = append(b.future, b.snapshot())
+	b.restore(b.history[len(b.history)-1])
+	b.history = b.history[:len(b.history)-1]
+	return nil
+}
+
+// Retract takes back the last move for good: unlike Undo it cannot be
+// redone.
+func (b *Board) Retract() error {
+	if len(b.history) == 0 {
+		return ErrNothingToUndo
+	}
+	b.restore(b.history[len(b.history)-1])
+	b.history = b.history[:len(b.history)-1]
+	return nil
+}
+
+// Moves is how many moves can be undone.
+func (b *Board) Moves() int { return len(b.history) }
+
+// Redo makes the last move undone again.
+func (b *Board) Redo() error {
+	if len(b.future) == 0 {
+		return ErrNothingToRedo
+	}
+	b.history = append(b.history, b.snapshot())
+	b.restore(b.future[len(b.future)-1])
+	b.future = b.future[:len(b.future)-1]
+	return nil
+}
+
+// NewBoard builds a Pending board from c, or says why c is no board as
+// Validate does.
+func NewBoard(c Config) (*Board, error) {
+	if err := Validate(c); err != nil {
+		return nil, err
+	}
+	grid := make([][]int, c.Height)
+	states := make([][]CellState, c.Height)
+	for y := range grid {
+		grid[y] = make([]int, c.Width)
+		states[y] = make([]CellState, c.Width)
+	}
+	return &Board{
+		config: c,
+		grid:   grid,
+		states: states,
+		state:  Pending,
+		flags:  c.Mines, // the same number of flags as mines
+	}, nil
+}
+
+// Config is the Config the board was built from.
+func (b *Board) Config() Config { return b.config }
+
+// Width is the number of columns of the board.
+func (b *Board) Width() int { return b.config.Width }
+
+// Height is the number of rows of the board.
+func (b *Board) Height() int { return b.config.Height }
+
+// State is how far the game has got.
+func (b *Board) State() GameState { return b.state }
+
+// FlagsLeft is the number of mines less the number of flags placed.
+func (b *Board) FlagsLeft() int { return b.flags }
+
+// Over reports whether the game has been won or lost.
+func (b *Board) Over() bool { return b.state == Won || b.state == Lost }
+
+// Inside reports whether (x, y) is a cell of the board.
+func (b *Board) Inside(x, y int) bool {
+	return x >= 0 && x < b.config.Width && y >= 0 && y < b.config.Height
+}
+
+// Cell returns the value and state of a cell. The value is 0 everywhere
+// while the board is Pending.
+func (b *Board) Cell(x, y int) (int, CellState) {
+	return b.grid[y][x], b.states[y][x]
+}
+
+func (b *Board) check(op string, x, y int) error {
+	if !b.Inside(x, y) {
+		return &MoveError{op, x, y, ErrOutOfBounds}
+	}
+	if b.Over() {
+		return &MoveError{op, x, y, ErrGameOver}
+	}
+	return nil
+}
+
+// Reveal shows a cell, and every cell around it when it is a 0. The first
+// reveal of a game places the mines, except on no guess boards: laying them
+// out can take a while, so Reveal returns ErrNotLaidOut and the caller opens
+// the board with a Layout from Generate instead.
+func (b *Board) Reveal(x, y int) error {
+	if err := b.check("reveal", x, y); err != nil {
+		return err
+	}
+	if b.states[y][x] == Revealed {
+		return &MoveError{"reveal", x, y, ErrRevealed}
+	}
+	if b.state == Pending {
+		if b.config.NoGuess {
+			return &MoveError{"reveal", x, y, ErrNotLaidOut}
+		}
+		l, err := Generate(b.config, Coord{x, y})
+		if err != nil {
+			return &MoveError{"reveal", x, y, err}
+		}
+		return b.Open(l)
+	}
+	b.record(b.snapshot())
+	if b.states[y][x] == Flagged {
+		b.flags += 1
+	}
+	b.reveal(x, y)
+	return nil
+}
+
+// Open places the mines of a Pending board as l lays them out and reveals
+// the cell l was laid out around.
+func (b *Board) Open(l *Layout) error {
+	x, y := l.first.Unwrap()
+	if err := b.check("reveal", x, y); err != nil {
+		return err
+	}
+	if b.state != Pending || l.config != b.config {
+		return &MoveError{"reveal", x, y, ErrLaidOut}
+	}
+	b.record(b.snapshot())
+	for row := range b.grid {
+		copy(b.grid[row], l.grid[row])
+	}
+	first := l.first
+	b.first = &first
+	if b.states[y][x] == Flagged {
+		b.flags += 1
+	}
+	b.reveal(x, y)
+	return nil
+}
+
+// reveal shows a cell and notes the mine it sets off, if any.
+func (b *Board) reveal(x, y int) {
+	show(b.grid, b.states, x, y)
+	b.state = evaluate(b.grid, b.states)
+	if b.state == Lost {
+		b.detonated = &Coord{x, y}
+	}
+}
+
+// Detonated returns the mine that lost the game.
+func (b *Board) Detonated() (Coord, bool) {
+	if b.detonated == nil {
+		return Coord{}, false
+	}
+	return *b.detonated, true
+}
+
+// First returns the cell revealed first, which the mines were laid out
+// around. Revealing it first on a board with the same Config lays out the
+// same mines.
+func (b *Board) First() (Coord, bool) {
+	if b.first == nil {
+		return Coord{}, false
+	}
+	return *b.first, true
+}
+
+// Chord reveals every hidden cell around a revealed number once as many
+// flags as the number surround it.
+func (b *Board) Chord(x, y int) error {
+	if err := b.check("chord", x, y); err != nil {
+		return err
+	}
+	if b.states[y][x] != Revealed {
+		return &MoveError{"chord", x, y, ErrHidden}
+	}
+	flags := 0
+	for _, c := range neighbours(x, y, b.config.Width, b.config.Height) {
+		if b.states[c.Y][c.X] == Flagged {
+			flags++
+		}
+	}
+	if flags != b.grid[y][x] {
+		return &MoveError{"chord", x, y, ErrChordFlags}
+	}
+	b.record(b.snapshot())
+	b.revealAround(x, y)
+	return nil
+}
+
+// AggressiveChord reveals every hidden cell around a revealed one, however
+// many flags surround it.
+func (b *Board) AggressiveChord(x, y int) error {
+	if err := b.check("chord", x, y); err != nil {
+		return err
+	}
+	if b.states[y][x] != Revealed {
+		return &MoveError{"chord", x, y, ErrHidden}
+	}
+	b.record(b.snapshot())
+	b.revealAround(x, y)
+	return nil
+}
+
+func (b *Board) revealAround(x, y int) {
+	for _, c := range neighbours(x, y, b.config.Width, b.config.Height) {
+		if b.states[c.Y][c.X] != Hidden {
+			continue
+		}
+		b.reveal(c.X, c.Y)
+		if b.Over() {
+			break
+		}
+	}
+}
+
+// Neighbours returns the cells around (x, y).
+func (b *Board) Neighbours(x, y int) []Coord {
+	return neighbours(x, y, b.config.Width, b.config.Height)
+}
+
+// ToggleFlag flags a hidden cell or takes the flag off a flagged one.
+func (b *Board) ToggleFlag(x, y int) error {
+	if err := b.check("flag", x, y); err != nil {
+		return err
+	}
+	if b.states[y][x] == Revealed {
+		return &MoveError{"flag", x, y, ErrRevealed}
+	}
+	b.record(b.snapshot())
+	switch b.states[y][x] {
+	case Flagged:
+		b.states[y][x] = Hidden
+		b.flags += 1
+	default:
+		b.states[y][x] = Flagged
+		b.flags -= 1
+	}
+	return nil
+}
@@ -0,0 +1,412 @@
+package engine
+
+import (
+	"errors"
+	"testing"
+)
+
+// newTestBoard builds a board already in play from rows of cells, '*' for a
+// mine and '.' for a safe cell.
+func newTestBoard(t *testing.T, rows ...string) *Board {
+	t.Helper()
+	g, mines := grid(rows...)
+	b, err := NewBoard(Config{Width: len(rows[0]), Height: len(rows), Mines: mines})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.grid = g
+	b.state = Playing
+	return b
+}
+
+// states draws the state of every cell: '#' hidden, 'F' flagged and the
+// number or '*' of a revealed one.
+func states(b *Board) []string {
+	rows := []string{}
+	for y := 0; y < b.Height(); y++ {
+		row := ""
+		for x := 0; x < b.Width(); x++ {
+			val, state := b.Cell(x, y)
+			switch {
+			case state == Hidden:
+				row += "#"
+			case state == Flagged:
+				row += "F"
+			case val == Mine:
+				row += "*"
+			default:
+				row += string(rune('0' + val))
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// a move made on a test board
+type move struct {
+	op   string
+	x, y int
+}
+
+func (m move) make(b *Board) error {
+	switch m.op {
+	case "reveal":
+		return b.Reveal(m.x, m.y)
+	case "chord":
+		return b.Chord(m.x, m.y)
+	case "flag":
+		return b.ToggleFlag(m.x, m.y)
+	case "undo":
+		return b.Undo()
+	case "redo":
+		return b.Redo()
+	case "retract":
+		return b.Retract()
+	}
+	panic("unknown move " + m.op)
+}
+
+func TestMoves(t *testing.T) {
+	board := []string{
+		"*...",
+		"....",
+		"...*",
+	}
+	tests := []struct {
+		name  string
+		moves []move
+		// err is what the last move returns
+		err   error
+		want  []string
+		state GameState
+		flags int
+	}{
+		{
+			name:  "reveal a number",
+			moves: []move{{"reveal", 1, 0}},
+			want:  []string{"#1##", "####", "####"},
+			state: Playing,
+			flags: 2,
+		},
+		{
+			name:  "reveal a 0 opens the cells around it",
+			moves: []move{{"reveal", 0, 2}},
+			want:  []string{"####", "111#", "001#"},
+			state: Playing,
+			flags: 2,
+		},
+		{
+			name:  "reveal a mine",
+			moves: []move{{"reveal", 0, 0}},
+			want:  []string{"*###", "####", "####"},
+			state: Lost,
+			flags: 2,
+		},
+		{
+			name:  "reveal a revealed cell",
+			moves: []move{{"reveal", 1, 0}, {"reveal", 1, 0}},
+			err:   ErrRevealed,
+			want:  []string{"#1##", "####", "####"},
+			state: Playing,
+			flags: 2,
+		},
+		{
+			name:  "reveal outside the board",
+			moves: []move{{"reveal", 4, 0}},
+			err:   ErrOutOfBounds,
+			want:  []string{"####", "####", "####"},
+			state: Playing,
+			flags: 2,
+		},
+		{
+			name:  "reveal after the game is lost",
+			moves: []move{{"reveal", 0, 0}, {"reveal", 1, 0}},
+			err:   ErrGameOver,
+			want:  []string{"*###", "####", "####"},
+			state: Lost,
+			flags: 2,
+		},
+		{
+			name:  "reveal a flagged cell takes its flag back",
+			moves: []move{{"flag", 1, 0}, {"reveal", 1, 0}},
+			want:  []string{"#1##", "####", "####"},
+			state: Playing,
+			flags: 2,
+		},
+		{
+			name:  "flag a cell",
+			moves: []move{{"flag", 0, 0}},
+			want:  []string{"F###", "####", "####"},
+			state: Playing,
+			flags: 1,
+		},
+		{
+			name:  "flag a flagged cell takes the flag off",
+			moves: []move{{"flag", 0, 0}, {"flag", 0, 0}},
+			want:  []string{"####", "####", "####"},
+			state: Playing,
+			flags: 2,
+		},
+		{
+			name:  "flag a revealed cell",
+			moves: []move{{"reveal", 1, 0}, {"flag", 1, 0}},
+			err:   ErrRevealed,
+			want:  []string{"#1##", "####", "####"},
+			state: Playing,
+			flags: 2,
+		},
+		{
+			name:  "chord a number with as many flags",
+			moves: []move{{"reveal", 1, 1}, {"flag", 0, 0}, {"chord", 1, 1}},
+			want:  []string{"F100", "1111", "001#"},
+			state: Won,
+			flags: 1,
+		},
+		{
+			name:  "chord a number with too few flags",
+			moves: []move{{"reveal", 1, 1}, {"chord", 1, 1}},
+			err:   ErrChordFlags,
+			want:  []string{"####", "#1##", "####"},
+			state: Playing,
+			flags: 2,
+		},
+		{
+			name:  "chord a hidden cell",
+			moves: []move{{"chord", 1, 1}},
+			err:   ErrHidden,
+			want:  []string{"####", "####", "####"},
+			state: Playing,
+			flags: 2,
+		},
+		{
+			name:  "chord around a wrong flag",
+			moves: []move{{"reveal", 1, 1}, {"flag", 1, 0}, {"chord", 1, 1}},
+			want:  []string{"*F##", "#1##", "####"},
+			state: Lost,
+			flags: 1,
+		},
+		{
+			name:  "undo a reveal",
+			moves: []move{{"reveal", 1, 0}, {"reveal", 3, 0}, {"undo", 0, 0}},
+			want:  []string{"#1##", "####", "####"},
+			state: Playing,
+			flags: 2,
+		},
+		{
+			name:  "undo the move that lost",
+			moves: []move{{"reveal", 1, 0}, {"reveal", 0, 0}, {"undo", 0, 0}},
+			want:  []string{"#1##", "####", "####"},
+			state: Playing,
+			flags: 2,
+		},
+		{
+			name:  "undo a flag",
+			moves: []move{{"flag", 0, 0}, {"undo", 0, 0}},
+			want:  []string{"####", "####", "####"},
+			state: Playing,
+			flags: 2,
+		},
+		{
+			name:  "undo with nothing to undo",
+			moves: []move{{"undo", 0, 0}},
+			err:   ErrNothingToUndo,
+			want:  []string{"####", "####", "####"},
+			state: Playing,
+			flags: 2,
+		},
+		{
+			name:  "redo an undone move",
+			moves: []move{{"reveal", 1, 0}, {"undo", 0, 0}, {"redo", 0, 0}},
+			want:  []string{"#1##", "####", "####"},
+			state: Playing,
+			flags: 2,
+		},
+		{
+			name:  "retract a reveal",
+			moves: []move{{"reveal", 1, 0}, {"reveal", 3, 0}, {"retract", 0, 0}},
+			want:  []string{"#1##", "####", "####"},
+			state: Playing,
+			flags: 2,
+		},
+		{
+			name:  "a retracted move cannot be redone",
+			moves: []move{{"reveal", 1, 0}, {"retract", 0, 0}, {"redo", 0, 0}},
+			err:   ErrNothingToRedo,
+			want:  []string{"####", "####", "####"},
+			state: Playing,
+			flags: 2,
+		},
+		{
+			name:  "a new move forgets the undone ones",
+			moves: []move{{"reveal", 1, 0}, {"undo", 0, 0}, {"flag", 0, 0}, {"redo", 0, 0}},
+			err:   ErrNothingToRedo,
+			want:  []string{"F###", "####", "####"},
+			state: Playing,
+			flags: 1,
+		},
+		{
+			name:  "reveal every safe cell",
+			moves: []move{{"reveal", 0, 2}, {"reveal", 2, 0}},
+			want:  []string{"#100", "1111", "001#"},
+			state: Won,
+			flags: 2,
+		},
+		{
+			name:  "flags do not win a game",
+			moves: []move{{"reveal", 0, 2}, {"flag", 0, 0}, {"flag", 3, 2}},
+			want:  []string{"F###", "111#", "001F"},
+			state: Playing,
+			flags: 0,
+		},
+		{
+			name:  "a flagged safe cell is still to be revealed",
+			moves: []move{{"reveal", 0, 2}, {"flag", 1, 0}, {"reveal", 2, 0}},
+			want:  []string{"#F00", "1111", "001#"},
+			state: Playing,
+			flags: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTestBoard(t, board...)
+			var err error
+			for _, m := range tt.moves {
+				err = m.make(b)
+			}
+			if !errors.Is(err, tt.err) {
+				t.Errorf("last move returned %v, want %v", err, tt.err)
+			}
+			if got := states(b); !equal(got, tt.want) {
+				t.Errorf("board is %q, want %q", got, tt.want)
+			}
+			if b.State() != tt.state {
+				t.Errorf("state is %v, want %v", b.State(), tt.state)
+			}
+			if b.FlagsLeft() != tt.flags {
+				t.Errorf("%d flags left, want %d", b.FlagsLeft(), tt.flags)
+			}
+		})
+	}
+}
+
+func TestDetonated(t *testing.T) {
+	b := newTestBoard(t, "*..", "...")
+	if _, ok := b.Detonated(); ok {
+		t.Error("a board in play has a detonated mine")
+	}
+	b.Reveal(0, 0)
+	if c, ok := b.Detonated(); !ok || c != (Coord{0, 0}) {
+		t.Errorf("detonated %v %v, want (0, 0)", c, ok)
+	}
+}
+
+func TestBatch(t *testing.T) {
+	b := newTestBoard(t, "*...", "....", "...*")
+	b.Batch(func() {
+		b.ToggleFlag(0, 0)
+		b.Reveal(1, 0)
+		b.Reveal(2, 0)
+	})
+	if err := b.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := states(b), []string{"####", "####", "####"}; !equal(got, want) {
+		t.Errorf("board is %q after undoing a batch, want %q", got, want)
+	}
+	if err := b.Undo(); !errors.Is(err, ErrNothingToUndo) {
+		t.Errorf("second undo returned %v, want %v", err, ErrNothingToUndo)
+	}
+}
+
+func TestFirstReveal(t *testing.T) {
+	tests := []struct {
+		rule FirstReveal
+		// safe is how many cells around and at the first reveal are clear
+		safe int
+	}{
+		{SafeCell, 1},
+		{SafeOpening, 9},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rule.String(), func(t *testing.T) {
+			for seed := int64(1); seed <= 200; seed++ {
+				// the board is full of mines but for the safe zone
+				c := Config{Width: 9, Height: 9, Mines: 81 - tt.safe, Rule: tt.rule, Seed: seed}
+				b, err := NewBoard(c)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := b.Reveal(4, 4); err != nil {
+					t.Fatal(err)
+				}
+				if b.State() == Lost {
+					t.Fatalf("seed %d: the first reveal hit a mine", seed)
+				}
+				zone := append([]Coord{{4, 4}}, b.Neighbours(4, 4)...)
+				for _, n := range zone[:tt.safe] {
+					if val, _ := b.Cell(n.X, n.Y); val == Mine {
+						t.Fatalf("seed %d: mine at %v in the safe zone", seed, n)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestSeed(t *testing.T) {
+	layout := func(c Config, first Coord) []string {
+		b, err := NewBoard(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b.Reveal(first.X, first.Y)
+		rows := []string{}
+		for y := 0; y < c.Height; y++ {
+			row := ""
+			for x := 0; x < c.Width; x++ {
+				if val, _ := b.Cell(x, y); val == Mine {
+					row += "*"
+				} else {
+					row += "."
+				}
+			}
+			rows = append(rows, row)
+		}
+		return rows
+	}
+	c := Config{Width: 16, Height: 16, Mines: 40, Rule: SafeOpening, Seed: 1234}
+	a := layout(c, Coord{3, 3})
+	if b := layout(c, Coord{3, 3}); !equal(a, b) {
+		t.Errorf("the same seed and first reveal laid out %q and %q", a, b)
+	}
+	// only the mines the other opening would have hit move
+	b := layout(c, Coord{12, 12})
+	moved := 0
+	for y := range a {
+		for x := range a[y] {
+			if a[y][x] == '*' && b[y][x] != '*' {
+				moved++
+			}
+		}
+	}
+	if moved > 9 {
+		t.Errorf("%d mines moved for another first reveal, at most 9 should", moved)
+	}
+	c.Seed = 4321
+	if b := layout(c, Coord{3, 3}); equal(a, b) {
+		t.Error("two seeds laid out the same board")
+	}
+}
@@ -0,0 +1,50 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+)
+
+// errors returned by NewBoard and Validate
+var (
+	ErrBoardSize    = errors.New("invalid board size")
+	ErrMineCount    = errors.New("invalid number of mines")
+	ErrTooManyMines = errors.New("cannot contain more mines than cells in grid")
+)
+
+// ErrNoGuessLayout is returned by Generate when no layout the solver could
+// clear was found, the board usually has too many mines
+var ErrNoGuessLayout = errors.New("could not lay out a board that needs no guessing")
+
+// errors returned by Undo and Redo
+var (
+	ErrNothingToUndo = errors.New("nothing to undo")
+	ErrNothingToRedo = errors.New("nothing to redo")
+)
+
+// errors wrapped in a MoveError when a move cannot be made
+var (
+	ErrOutOfBounds = errors.New("cell is out of bounds")
+	ErrGameOver    = errors.New("game is over")
+	ErrRevealed    = errors.New("cell is already revealed")
+	ErrHidden      = errors.New("cell is not revealed")
+	ErrChordFlags  = errors.New("flags around the cell do not match its number")
+	// ErrNotLaidOut means the first reveal of a no guess board has to open
+	// it with a Layout, and ErrLaidOut that the board has its mines already
+	ErrNotLaidOut = errors.New("board has not been laid out")
+	ErrLaidOut    = errors.New("board has already been laid out")
+)
+
+// MoveError describes a move the board refused. Use errors.Is to find out
+// why.
+type MoveError struct {
+	Op   string
+	X, Y int
+	Err  error
+}
+
+func (e *MoveError) Error() string {
+	return fmt.Sprintf("%s (%d, %d): %v", e.Op, e.X, e.Y, e.Err)
+}
+
+func (e *MoveError) Unwrap() error { return e.Err }
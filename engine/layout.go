@@ -0,0 +1,115 @@
+package engine
+
+import "math/rand"
+
+// Layout is where the mines of a board go, laid out around its first reveal.
+type Layout struct {
+	config Config
+	first  Coord
+	grid   [][]int
+}
+
+// maxAttempts bounds the layouts a no guess board starts over from when
+// repairing one fails
+const maxAttempts = 100
+
+// Generate lays out the mines of a board built from c for a first reveal at
+// first. The seed's layout is used as it is unless it needs guessing on a no
+// guess board, where mines are moved until the solver can clear it. That
+// can take a while on large boards.
+func Generate(c Config, first Coord) (*Layout, error) {
+	if err := Validate(c); err != nil {
+		return nil, err
+	}
+	if first.X < 0 || first.X >= c.Width || first.Y < 0 || first.Y >= c.Height {
+		return nil, ErrOutOfBounds
+	}
+	l := &Layout{config: c, first: first, grid: make([][]int, c.Height)}
+	for y := range l.grid {
+		l.grid[y] = make([]int, c.Width)
+	}
+	safe := c.safeZone(first.X, first.Y)
+	if !c.NoGuess {
+		if err := placeMines(l.grid, c.Mines, c.Seed, safe); err != nil {
+			return nil, err
+		}
+		return l, nil
+	}
+	r := rand.New(rand.NewSource(c.Seed))
+	seed := c.Seed
+	for i := 0; i < maxAttempts; i++ {
+		l.clear()
+		if err := placeMines(l.grid, c.Mines, seed, safe); err != nil {
+			return nil, err
+		}
+		if repair(l.grid, c.Mines, first, r) && solvable(l.grid, c.Mines, first) {
+			return l, nil
+		}
+		seed = r.Int63()
+	}
+	return nil, ErrNoGuessLayout
+}
+
+func (l *Layout) clear() {
+	for y := range l.grid {
+		for x := range l.grid[y] {
+			l.grid[y][x] = 0
+		}
+	}
+}
+
+// repair moves mines until the solver can clear grid from start. Whenever
+// the solver is stuck a mine on the edge of what it has revealed moves to a
+// cell it has not reached yet and it carries on. That lowers the revealed
+// numbers around the mine, which the solver reads again, but what it
+// deduced from them before is kept, so the grid repair leaves needs
+// checking from start again. repair reports whether the solver cleared it.
+func repair(grid [][]int, mines int, start Coord, r *rand.Rand) bool {
+	if grid[start.Y][start.X] == Mine {
+		return false
+	}
+	s := newSolver(grid, mines, start)
+	for moves := 0; moves < s.width*s.height; moves++ {
+		if s.solve() {
+			return true
+		}
+		edge, unreached := s.unknown()
+		from := []Coord{}
+		for _, c := range edge {
+			if grid[c.Y][c.X] == Mine {
+				from = append(from, c)
+			}
+		}
+		to := []Coord{}
+		for _, c := range unreached {
+			if grid[c.Y][c.X] != Mine {
+				to = append(to, c)
+			}
+		}
+		if len(from) == 0 || len(to) == 0 {
+			return false
+		}
+		moveMine(grid, from[r.Intn(len(from))], to[r.Intn(len(to))])
+	}
+	return false
+}
+
+// moveMine takes the mine at from to the empty cell to, counting the
+// numbers around both again.
+func moveMine(grid [][]int, from, to Coord) {
+	width, height := len(grid[0]), len(grid)
+	grid[from.Y][from.X] = 0
+	for _, n := range neighbours(from.X, from.Y, width, height) {
+		if grid[n.Y][n.X] == Mine {
+			grid[from.Y][from.X]++
+			continue
+		}
+		grid[n.Y][n.X]--
+	}
+	grid[to.Y][to.X] = Mine
+	for _, n := range neighbours(to.X, to.Y, width, height) {
+		if grid[n.Y][n.X] != Mine {
+			grid[n.Y][n.X]++
+		}
+	}
+}
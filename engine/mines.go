@@ -0,0 +1,116 @@
+package engine
+
+import "math/rand"
+
+// placeMines lays out n mines, keeping clear of safe. The seed alone ranks
+// every cell of the grid and the mines go in the first n cells of that
+// ranking outside of safe, so a different first reveal only moves the mines
+// it would have hit.
+func placeMines(grid [][]int, n int, seed int64, safe []Coord) error {
+	src := rand.NewSource(seed)
+	r := rand.New(src)
+	nRows, nCols := len(grid), len(grid[0])
+	excluded := map[Coord]bool{}
+	for _, c := range safe {
+		excluded[c] = true
+	}
+	ranked := []Coord{}
+	for y := range grid {
+		for x := range grid[y] {
+			ranked = append(ranked, Coord{x, y})
+		}
+	}
+	r.Shuffle(len(ranked), func(i, j int) {
+		ranked[i], ranked[j] = ranked[j], ranked[i]
+	})
+	coords := []Coord{}
+	for _, c := range ranked {
+		if !excluded[c] {
+			coords = append(coords, c)
+		}
+	}
+
+	if n > len(coords) {
+		return ErrTooManyMines
+	}
+
+	for i := 0; i < n; i++ {
+		x, y := coords[i].Unwrap()
+
+		grid[y][x] = Mine
+
+		for _, mod := range neighbours(x, y, nCols, nRows) {
+			mx, my := mod.Unwrap()
+			if grid[my][mx] == Mine {
+				continue
+			}
+			grid[my][mx] += 1
+		}
+	}
+
+	return nil
+}
+
+// neighbours returns the cells around (x, y) that lie on a width by height
+// grid.
+func neighbours(x, y, width, height int) []Coord {
+	adjacent := []Coord{
+		{x - 1, y - 1}, {x, y - 1}, {x + 1, y - 1},
+		{x - 1, y}, {x + 1, y},
+		{x - 1, y + 1}, {x, y + 1}, {x + 1, y + 1},
+	}
+	inside := adjacent[:0]
+	for _, c := range adjacent {
+		if c.X < 0 || c.X > width-1 || c.Y < 0 || c.Y > height-1 {
+			continue
+		}
+		inside = append(inside, c)
+	}
+	return inside
+}
+
+// show reveals (initX, initY) and, breadth first, every cell around a 0.
+func show(grid [][]int, states [][]CellState, initX, initY int) {
+	queue := []Coord{{initX, initY}}
+	nx, ny := len(grid[0]), len(grid)
+
+	for len(queue) > 0 {
+		x, y := queue[0].Unwrap()
+		queue = queue[1:]
+		if states[y][x] == Revealed {
+			continue
+		}
+		states[y][x] = Revealed
+
+		// only a 0 lets us show all of the cells around it
+		if grid[y][x] != 0 {
+			continue
+		}
+		for _, c := range neighbours(x, y, nx, ny) {
+			if states[c.Y][c.X] == Hidden {
+				queue = append(queue, c)
+			}
+		}
+	}
+}
+
+// evaluate decides whether a game is lost, won or still being played. It is
+// won once every cell without a mine is revealed, flagged ones included.
+func evaluate(grid [][]int, states [][]CellState) GameState {
+	var hasHidden bool
+	for y := range states {
+		for x := range states[y] {
+			state, val := states[y][x], grid[y][x]
+			if val == Mine && state == Revealed {
+				return Lost
+			}
+			if val != Mine && state != Revealed {
+				hasHidden = true
+			}
+		}
+	}
+	if hasHidden {
+		return Playing
+	}
+	return Won
+}
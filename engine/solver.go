@@ -0,0 +1,210 @@
+package engine
+
+// constraint says that exactly mines of cells hold a mine. at is the
+// revealed cell it was read from.
+type constraint struct {
+	at    Coord
+	cells []Coord
+	mines int
+}
+
+// solver replays a game on a laid out grid using only what a player could
+// deduce, to find out whether the board can be cleared without guessing.
+type solver struct {
+	grid   [][]int
+	states [][]CellState
+	// mines counts the mines not yet flagged
+	mines         int
+	width, height int
+	// done marks the revealed cells with nothing hidden around them left,
+	// which they never will have again
+	done [][]bool
+}
+
+// solvable reports whether grid can be cleared from a first reveal at start
+// by single cell and subset reasoning alone.
+func solvable(grid [][]int, mines int, start Coord) bool {
+	if grid[start.Y][start.X] == Mine {
+		return false
+	}
+	return newSolver(grid, mines, start).solve()
+}
+
+// newSolver starts a solver off by revealing start, which must not be a
+// mine.
+func newSolver(grid [][]int, mines int, start Coord) *solver {
+	s := &solver{
+		grid:   grid,
+		states: make([][]CellState, len(grid)),
+		mines:  mines,
+		width:  len(grid[0]),
+		height: len(grid),
+		done:   make([][]bool, len(grid)),
+	}
+	for y := range s.states {
+		s.states[y] = make([]CellState, s.width)
+		s.done[y] = make([]bool, s.width)
+	}
+	show(s.grid, s.states, start.X, start.Y)
+	return s
+}
+
+// solve deduces what it can and reports whether that cleared the board.
+// Until it does it can be called again once the grid has changed.
+func (s *solver) solve() bool {
+	for evaluate(s.grid, s.states) != Won {
+		if !s.step() {
+			return false
+		}
+	}
+	return true
+}
+
+// unknown returns the hidden cells next to a revealed one, the edge the
+// solver got stuck on, and those it has not reached yet.
+func (s *solver) unknown() (edge, unreached []Coord) {
+	for y := range s.states {
+		for x := range s.states[y] {
+			if s.states[y][x] != Hidden {
+				continue
+			}
+			reached := false
+			for _, n := range neighbours(x, y, s.width, s.height) {
+				if s.states[n.Y][n.X] == Revealed {
+					reached = true
+					break
+				}
+			}
+			if reached {
+				edge = append(edge, Coord{x, y})
+			} else {
+				unreached = append(unreached, Coord{x, y})
+			}
+		}
+	}
+	return edge, unreached
+}
+
+// step makes every deduction it can from the current constraints and
+// reports whether it made any.
+func (s *solver) step() bool {
+	constraints := s.constraints()
+	progress := false
+	for _, c := range constraints {
+		progress = s.settle(c) || progress
+	}
+	if progress {
+		return true
+	}
+
+	// when one constraint's cells are a subset of another's, the cells
+	// left over hold the difference in mines
+	for i, a := range constraints {
+		for j, b := range constraints {
+			// only the cells of numbers at most two apart can overlap
+			if abs(a.at.X-b.at.X) > 2 || abs(a.at.Y-b.at.Y) > 2 {
+				continue
+			}
+			if i == j || len(a.cells) >= len(b.cells) || !subset(a.cells, b.cells) {
+				continue
+			}
+			progress = s.settle(constraint{cells: difference(b.cells, a.cells), mines: b.mines - a.mines}) || progress
+		}
+		if progress {
+			return true
+		}
+	}
+
+	// the number of mines left applies to every unknown cell
+	unknown := []Coord{}
+	for y := range s.states {
+		for x := range s.states[y] {
+			if s.states[y][x] == Hidden {
+				unknown = append(unknown, Coord{x, y})
+			}
+		}
+	}
+	return s.settle(constraint{cells: unknown, mines: s.mines})
+}
+
+// constraints collects one constraint per revealed cell touching unknown
+// cells. Only a 0 whose mine repair moved away can touch any.
+func (s *solver) constraints() []constraint {
+	constraints := []constraint{}
+	for y := range s.states {
+		for x := range s.states[y] {
+			if s.states[y][x] != Revealed || s.done[y][x] {
+				continue
+			}
+			c := constraint{at: Coord{x, y}, mines: s.grid[y][x]}
+			for _, n := range neighbours(x, y, s.width, s.height) {
+				switch s.states[n.Y][n.X] {
+				case Hidden:
+					c.cells = append(c.cells, n)
+				case Flagged:
+					c.mines--
+				}
+			}
+			if len(c.cells) == 0 {
+				s.done[y][x] = true
+				continue
+			}
+			constraints = append(constraints, c)
+		}
+	}
+	return constraints
+}
+
+// settle flags or reveals every cell of c when c leaves no doubt about them.
+func (s *solver) settle(c constraint) bool {
+	if len(c.cells) == 0 || (c.mines != 0 && c.mines != len(c.cells)) {
+		return false
+	}
+	progress := false
+	for _, cell := range c.cells {
+		if s.states[cell.Y][cell.X] != Hidden {
+			continue
+		}
+		progress = true
+		if c.mines == 0 {
+			show(s.grid, s.states, cell.X, cell.Y)
+			continue
+		}
+		s.states[cell.Y][cell.X] = Flagged
+		s.mines--
+	}
+	return progress
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func subset(a, b []Coord) bool {
+	for _, ca := range a {
+		found := false
+		for _, cb := range b {
+			if ca == cb {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func difference(b, a []Coord) []Coord {
+	diff := []Coord{}
+	for _, cb := range b {
+		if !subset([]Coord{cb}, a) {
+			diff = append(diff, cb)
+		}
+	}
+	return diff
+}
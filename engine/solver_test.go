@@ -0,0 +1,219 @@
+package engine
+
+import (
+	"errors"
+	"testing"
+)
+
+// grid lays out rows of cells, '*' for a mine and '.' for a safe cell, with
+// their numbers.
+func grid(rows ...string) ([][]int, int) {
+	g := make([][]int, len(rows))
+	mines := 0
+	for y, row := range rows {
+		g[y] = make([]int, len(row))
+		for x, cell := range row {
+			if cell == '*' {
+				g[y][x] = Mine
+				mines++
+			}
+		}
+	}
+	for y := range g {
+		for x := range g[y] {
+			if g[y][x] != Mine {
+				g[y][x] = mineCount(g, x, y)
+			}
+		}
+	}
+	return g, mines
+}
+
+func mineCount(g [][]int, x, y int) int {
+	n := 0
+	for _, c := range neighbours(x, y, len(g[0]), len(g)) {
+		if g[c.Y][c.X] == Mine {
+			n++
+		}
+	}
+	return n
+}
+
+func TestSolvable(t *testing.T) {
+	tests := []struct {
+		name  string
+		rows  []string
+		start Coord
+		want  bool
+	}{
+		{
+			name:  "an opening reveals every safe cell",
+			rows:  []string{"....", "....", "...*"},
+			start: Coord{0, 0},
+			want:  true,
+		},
+		{
+			name:  "single cell reasoning",
+			rows:  []string{"....", "*...", "...."},
+			start: Coord{3, 0},
+			want:  true,
+		},
+		{
+			name:  "two cells with a mine between them",
+			rows:  []string{"..", "..", "*."},
+			start: Coord{0, 0},
+			want:  false,
+		},
+		{
+			name:  "starting on a mine",
+			rows:  []string{"*.", ".."},
+			start: Coord{0, 0},
+			want:  false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, mines := grid(tt.rows...)
+			if got := solvable(g, mines, tt.start); got != tt.want {
+				t.Errorf("solvable is %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateNoGuess(t *testing.T) {
+	sizes := []Config{
+		{Width: 9, Height: 9, Mines: 10},
+		{Width: 16, Height: 16, Mines: 40},
+		{Width: 30, Height: 16, Mines: 99},
+		{Width: 30, Height: 16, Mines: 170},
+	}
+	for _, c := range sizes {
+		c.NoGuess = true
+		corners := []Coord{{0, 0}, {c.Width - 1, c.Height - 1}, {c.Width / 2, c.Height / 2}}
+		for seed := int64(1); seed <= 10; seed++ {
+			for _, first := range corners {
+				c.Seed = seed
+				l, err := Generate(c, first)
+				if err != nil {
+					t.Fatalf("%dx%d/%d seed %d at %v: %v", c.Width, c.Height, c.Mines, seed, first, err)
+				}
+				mines := 0
+				for y := range l.grid {
+					for x, val := range l.grid[y] {
+						if val == Mine {
+							mines++
+						} else if val != mineCount(l.grid, x, y) {
+							t.Fatalf("seed %d: (%d, %d) is %d, but %d mines are around it", seed, x, y, val, mineCount(l.grid, x, y))
+						}
+					}
+				}
+				if mines != c.Mines {
+					t.Fatalf("seed %d: %d mines laid out, want %d", seed, mines, c.Mines)
+				}
+				for _, s := range c.safeZone(first.X, first.Y) {
+					if l.grid[s.Y][s.X] == Mine {
+						t.Fatalf("seed %d: mine at %v in the opening around %v", seed, s, first)
+					}
+				}
+				if !solvable(l.grid, c.Mines, first) {
+					t.Fatalf("seed %d at %v: the layout needs guessing", seed, first)
+				}
+			}
+		}
+	}
+}
+
+func TestGenerateKeepsTheSeed(t *testing.T) {
+	c := Config{Width: 16, Height: 16, Mines: 40, Rule: SafeOpening}
+	first := Coord{8, 8}
+	kept := 0
+	for seed := int64(1); seed <= 50; seed++ {
+		c.Seed, c.NoGuess = seed, false
+		plain, err := Generate(c, first)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.NoGuess = true
+		a, err := Generate(c, first)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := Generate(c, first)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for y := range a.grid {
+			for x := range a.grid[y] {
+				if a.grid[y][x] != b.grid[y][x] {
+					t.Fatalf("seed %d laid out two no guess boards", seed)
+				}
+			}
+		}
+		if !solvable(plain.grid, c.Mines, first) {
+			continue
+		}
+		// a layout that needs no guessing is used as it is
+		kept++
+		for y := range a.grid {
+			for x := range a.grid[y] {
+				if a.grid[y][x] != plain.grid[y][x] {
+					t.Fatalf("seed %d: the no guess layout differs from a solvable one", seed)
+				}
+			}
+		}
+	}
+	if kept == 0 {
+		t.Error("no seed laid out a board that needs no guessing")
+	}
+}
+
+func TestGenerateFails(t *testing.T) {
+	// the last mine is always between two cells
+	c := Config{Width: 4, Height: 2, Mines: 1, NoGuess: true, Seed: 1}
+	if _, err := Generate(c, Coord{0, 0}); !errors.Is(err, ErrNoGuessLayout) {
+		t.Errorf("Generate returned %v, want %v", err, ErrNoGuessLayout)
+	}
+	c = Config{Width: 9, Height: 9, Mines: 10}
+	if _, err := Generate(c, Coord{9, 0}); !errors.Is(err, ErrOutOfBounds) {
+		t.Errorf("Generate returned %v, want %v", err, ErrOutOfBounds)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	c := Config{Width: 9, Height: 9, Mines: 10, NoGuess: true, Seed: 7}
+	b, err := NewBoard(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Reveal(4, 4); !errors.Is(err, ErrNotLaidOut) {
+		t.Fatalf("Reveal returned %v, want %v", err, ErrNotLaidOut)
+	}
+	l, err := Generate(c, Coord{4, 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := c
+	other.Seed++
+	if err := b.Open(&Layout{config: other, first: l.first, grid: l.grid}); !errors.Is(err, ErrLaidOut) {
+		t.Errorf("Open with another board's layout returned %v, want %v", err, ErrLaidOut)
+	}
+	if err := b.Open(l); err != nil {
+		t.Fatal(err)
+	}
+	if first, ok := b.First(); !ok || first != (Coord{4, 4}) {
+		t.Errorf("first reveal is %v %v, want (4, 4)", first, ok)
+	}
+	if _, state := b.Cell(4, 4); state != Revealed || b.State() != Playing {
+		t.Errorf("the first cell is %v and the game %v after opening", state, b.State())
+	}
+	if err := b.Open(l); !errors.Is(err, ErrLaidOut) {
+		t.Errorf("opening twice returned %v, want %v", err, ErrLaidOut)
+	}
+	if err := b.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	if b.State() != Pending {
+		t.Errorf("undoing the opening left the game %v, want %v", b.State(), Pending)
+	}
+}
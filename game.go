@@ -2,6 +2,7 @@ package main
 
 import (
 	"errors"
+	"log"
 	"math/rand"
 	"strconv"
 	"strings"
@@ -10,15 +11,7 @@ import (
 	"github.com/charmbracelet/bubbles/stopwatch"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
-)
-
-type gameState int64
-
-const (
-	pendingGame gameState = iota
-	playableGame
-	wonGame
-	lostGame
+	"github.com/ethanefung/minesweeper/engine"
 )
 
 type gameMode int64
@@ -30,6 +23,15 @@ const (
 	custom
 )
 
+func parseGameMode(s string) (gameMode, error) {
+	for _, gm := range []gameMode{beginner, intermediate, expert, custom} {
+		if gm.String() == s {
+			return gm, nil
+		}
+	}
+	return custom, errors.New("unknown game mode: " + s)
+}
+
 func (gm gameMode) String() string {
 	switch gm {
 	case beginner:
@@ -45,347 +47,529 @@ func (gm gameMode) String() string {
 	}
 }
 
-type cellState int64
+// boardSize is the shape of a board and how many mines it holds.
+type boardSize struct {
+	width, height, mines int
+}
 
-const (
-	hidden cellState = iota
-	revealed
-	flagged
-	focused
-)
+func (bs boardSize) String() string {
+	return strconv.Itoa(bs.width) + "x" + strconv.Itoa(bs.height) + "/" + strconv.Itoa(bs.mines)
+}
 
-type coord struct {
-	x, y int
-}
-
-func (c coord) unwrap() (int, int) { return c.x, c.y }
-
-var baseStyle = lipgloss.NewStyle().Width(3).Height(1).Align(lipgloss.Center)
-var hiddenStyle = baseStyle.Copy().Background(lipgloss.Color("#2e2e2e"))
-var flaggedStyle = baseStyle.Copy()
-var revealedStyles = []lipgloss.Style{
-	// first will be explosive style
-	baseStyle.Copy().Background(lipgloss.Color("#F00")).Foreground(lipgloss.Color("#000")),
-	// next will be 0,
-	baseStyle.Copy().Foreground(lipgloss.Color("#000")),
-	//blue
-	baseStyle.Copy().Foreground(lipgloss.Color("#00F")),
-	//green
-	baseStyle.Copy().Foreground(lipgloss.Color("#0F0")),
-	// red
-	baseStyle.Copy().Foreground(lipgloss.Color("#F00")),
-	// purple
-	baseStyle.Copy().Foreground(lipgloss.Color("#800080")),
-	// black // since this is meant for dark mode, render white instead
-	baseStyle.Copy(),
-	// gray
-	baseStyle.Copy().Foreground(lipgloss.Color("#808080")),
-	// maroon
-	baseStyle.Copy().Foreground(lipgloss.Color("#80000")),
-	// turquoise
-	baseStyle.Copy().Foreground(lipgloss.Color("#0FF")),
-}
-var digitsStyle = baseStyle.Copy().Foreground(lipgloss.Color("#F00"))
-
-func createFocusedStyle(style lipgloss.Style) lipgloss.Style {
-	return style.Copy().Background(lipgloss.Color("#696969"))
-}
-func (s cellState) view(val int, focused bool) string {
-	switch s {
-	case hidden:
-		style := hiddenStyle
-		if focused {
-			style = createFocusedStyle(style)
-		}
-		return style.Render(" ")
-	case flagged:
-		style := flaggedStyle
-		if focused {
-			style = createFocusedStyle(style)
-		}
-		return style.Render("🚩")
+func (bs boardSize) config(rule engine.FirstReveal, noGuess bool, seed int64) engine.Config {
+	return engine.Config{
+		Width:   bs.width,
+		Height:  bs.height,
+		Mines:   bs.mines,
+		Rule:    rule,
+		Seed:    seed,
+		NoGuess: noGuess,
 	}
-	var style lipgloss.Style = revealedStyles[val+1]
-	if val == -1 {
-		return style.Render("*")
+}
+
+var presets = map[gameMode]boardSize{
+	beginner:     {9, 9, 10},
+	intermediate: {16, 16, 40},
+	expert:       {30, 16, 99},
+}
+
+// newSeed picks a seed short enough to read out to a teammate.
+func newSeed() int64 {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	return r.Int63n(1_000_000_000) + 1
+}
+
+// game drives an engine.Board from the keyboard and draws it.
+type game struct {
+	model   *model
+	board   *engine.Board
+	cursor  engine.Coord
+	mode    gameMode
+	rule    engine.FirstReveal
+	noGuess bool
+	// practice games can take back the move that lost them
+	practice bool
+	// usedUndo games are saved unranked
+	usedUndo bool
+	// count holds the digits typed before a command, as in vim's "5j"
+	count string
+	// pending holds the start of a command that takes more than one key,
+	// like the first g of gg
+	pending string
+	// direction is the way the last h, j, k or l went. A counted x or f
+	// works along it.
+	direction engine.Coord
+	// fixedSeed is the seed the player asked for, 0 rolls a new seed on
+	// every board
+	fixedSeed int64
+	stopwatch stopwatch.Model
+	// message explains why the last move could not be made, if it matters
+	message string
+	// command is the line typed after ':' and searchLine the one typed
+	// after '/'. pattern is what was last searched for.
+	command    *prompt
+	searchLine *prompt
+	pattern    string
+	// visual is the visual mode the player is in, selecting the cells
+	// between anchor and the cursor
+	visual visualMode
+	anchor engine.Coord
+	// marks are set with m{a-z}. jumps is the jumplist, jumpIndex where
+	// ctrl+o and ctrl+i are in it.
+	marks     map[byte]engine.Coord
+	jumps     []engine.Coord
+	jumpIndex int
+	// typed are the keys of the command being typed and changed whether it
+	// changes the board. lastChange is what '.' repeats.
+	typed      []string
+	changed    bool
+	lastChange *change
+	// recording is the register q is recording keys into, replaying how
+	// deep in playing macros the game is and replayed how many keys they
+	// have typed. quitID tells the timeout of a lone q, which quits, from
+	// those of earlier ones.
+	recording string
+	recorded  []string
+	replaying int
+	replayed  int
+	quitID    int
+	// operator is the x, d or f waiting for a motion or text object and
+	// operatorCount the count typed before it. operatorTyped is how many
+	// keys of the command had been typed with it, operatorActed whether
+	// acting on the cursor changed the board and operatorID tells its
+	// timeout from the timeouts of earlier operators.
+	operator      string
+	operatorCount int
+	operatorTyped int
+	operatorActed bool
+	operatorID    int
+	// top and left are the first row and column the viewport shows.
+	// scrollAmount is how far ctrl+d and ctrl+u scroll, 0 for half of it.
+	top          int
+	left         int
+	scrollAmount int
+	// inMinimap is whether keys move mapCursor around the minimap rather
+	// than the cursor around the board
+	inMinimap bool
+	mapCursor engine.Coord
+	// layingOut is whether the no guess board is being laid out
+	layingOut bool
+	// pulse highlights the neighbours of a number 'd' refused to chord
+	pulse   *engine.Coord
+	pulseID int
+}
+
+type pulseEndMsg struct {
+	id int
+}
+
+const pulseDuration = 200 * time.Millisecond
+
+func (g *game) startPulse() tea.Cmd {
+	c := g.cursor
+	g.pulse = &c
+	g.pulseID++
+	id := g.pulseID
+	return tea.Tick(pulseDuration, func(time.Time) tea.Msg {
+		return pulseEndMsg{id}
+	})
+}
+
+func (g *game) pulsing(c engine.Coord) bool {
+	if g.pulse == nil {
+		return false
 	}
-	if focused {
-		style = createFocusedStyle(style)
+	for _, n := range g.board.Neighbours(g.pulse.Unwrap()) {
+		if n == c {
+			return true
+		}
 	}
-	return style.Render(strconv.Itoa(val))
+	return false
 }
 
-func evaluate(grid [][]int, states [][]cellState) gameState {
-	var hasHidden bool
-	for y := range states {
-		for x := range states[y] {
-			state, val := states[y][x], grid[y][x]
-			if val == -1 && state == revealed {
-				return lostGame
-			}
-			if val != -1 && state == hidden {
-				hasHidden = true
-			}
+func NewGame(model *model) *game {
+	return &game{
+		model:      model,
+		cursor:     engine.Coord{},
+		direction:  engine.Coord{X: 1},
+		stopwatch:  stopwatch.New(),
+		command:    newPrompt(":"),
+		searchLine: newPrompt("/"),
+	}
+}
+
+func (g *game) update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case pulseEndMsg:
+		if msg.id == g.pulseID {
+			g.pulse = nil
+		}
+		return g.model, nil
+	case layoutMsg:
+		return g.model, g.opened(msg)
+	case quitTimeoutMsg:
+		if msg.id == g.quitID && g.pending == "q" {
+			return g.model, tea.Quit
 		}
+		return g.model, nil
+	case operatorTimeoutMsg:
+		if msg.id != g.operatorID || g.operator == "" {
+			return g.model, nil
+		}
+		if g.recording != "" && g.replaying == 0 {
+			g.recorded = append(g.recorded, timeoutKey)
+		}
+		g.timeout()
+		return g.model, nil
+	case tea.KeyMsg:
+		// the terminal may have changed size since the board was drawn
+		g.follow()
+		if g.recording != "" && g.replaying == 0 {
+			g.recorded = append(g.recorded, msg.String())
+		}
+		if g.command.active() {
+			return g.model, g.command.handle(msg, g.execute, completions)
+		}
+		if g.searchLine.active() {
+			return g.model, g.searchLine.handle(msg, g.search, searchCompletions)
+		}
+		key := msg.String()
+		// the key after m, ', `, q or @ names a mark or register, and the
+		// digits after the first of a count are always more of it
+		if !nameKeys[g.pending] && !(g.count != "" && isDigit(key)) {
+			key = g.model.keys.game.translate(key)
+		}
+		if key == "ctrl+c" {
+			return g.model, tea.Quit
+		}
+		if key == "" {
+			return g.model, nil
+		}
+		return g.model, g.input(key)
+	}
+	var cmd tea.Cmd
+	g.stopwatch, cmd = g.stopwatch.Update(msg)
+	// keeps the cursor of the command or search line blinking
+	if g.command.active() {
+		return g.model, tea.Batch(cmd, g.command.update(msg))
 	}
-	if hasHidden {
-		return playableGame
+	if g.searchLine.active() {
+		return g.model, tea.Batch(cmd, g.searchLine.update(msg))
 	}
-	return wonGame
+	return g.model, cmd
 }
 
-func placeMines(grid [][]int, n int) error {
-	src := rand.NewSource(time.Now().UnixNano())
-	r := rand.New(src)
-	if len(grid) <= 0 {
-		return errors.New("cannot place mines on a non-existent grid")
+// press handles a key typed on the board.
+func (g *game) press(key string) tea.Cmd {
+	if g.pending != "" {
+		key = g.pending + key
+		g.pending = ""
+	} else if prefixKeys[key] || (g.operator != "" && objectKeys[key]) {
+		g.pending = key
+		if key == "q" {
+			return g.waitToQuit()
+		}
+		return nil
+	} else if g.countDigit(key) {
+		return nil
+	}
+	if key == "w" && g.board.State() == engine.Won {
+		// nothing is left to move to on a won board, so w saves it
+		g.model.current = g.model.saveMenu
+		return nil
+	}
+	counted := g.count != ""
+	n := g.takeCount()
+	if g.inMinimap {
+		g.updateMinimap(key, n)
+		return nil
 	}
-	nRows, nCols := len(grid), len(grid[0])
-	coords := []coord{}
-	for y := range grid {
-		for x := range grid[y] {
-			coords = append(coords, coord{x, y})
+	if g.operator != "" {
+		return g.operate(key, n, counted)
+	}
+	if target, ok := g.motion(key, n, counted); ok {
+		if jumpMotions[key] {
+			g.jump(target)
 		}
+		g.cursor = target
+		return nil
 	}
-
-	if n > len(coords) {
-		return errors.New("cannot contain more mines than cells in grid")
+	if g.updateMark(key) {
+		return nil
+	}
+	if g.updateScroll(key) {
+		return nil
 	}
+	if cmd, ok := g.updateMacro(key, n); ok {
+		return cmd
+	}
+	if g.visual != noVisual {
+		if cmd, ok := g.updateVisual(key); ok {
+			return cmd
+		}
+	}
+	switch key {
+	case "h":
+		g.step(engine.Coord{X: -1}, n)
+	case "j":
+		g.step(engine.Coord{Y: 1}, n)
+	case "k":
+		g.step(engine.Coord{Y: -1}, n)
+	case "l":
+		g.step(engine.Coord{X: 1}, n)
+	case "x", "d", "f":
+		if g.model.settings.operatorPending {
+			return g.startOperator(key, n)
+		}
+		return g.act(key, n)
+	case "u":
+		return g.undo()
+	case "ctrl+r":
+		return g.redo()
+	case "r":
+		return g.restart()
+	case ":":
+		g.message = ""
+		return g.command.open()
+	case "ctrl+o":
+		g.jumpBack(n)
+	case "tab":
+		// terminals send ctrl+i as tab
+		g.jumpForward(n)
+	case "/":
+		g.message = ""
+		return g.searchLine.open()
+	case "n":
+		g.searchNext(n, 1)
+	case "N":
+		g.searchNext(n, -1)
+	case "ctrl+e":
+		g.scroll(n)
+	case "ctrl+y":
+		g.scroll(-n)
+	case "ctrl+d":
+		g.scrollHalf(n, counted, 1)
+	case "ctrl+u":
+		g.scrollHalf(n, counted, -1)
+	case "ctrl+w":
+		g.openMinimap()
+	case "v":
+		g.startVisual(charVisual)
+	case "ctrl+v":
+		g.startVisual(blockVisual)
+	}
+	return nil
+}
 
-	r.Shuffle(len(coords), func(i, j int) {
-		coords[i], coords[j] = coords[j], coords[i]
-	})
+// restart lays out a new board of the same size.
+func (g *game) restart() tea.Cmd {
+	g.setGrid(g.board.Width(), g.board.Height(), g.board.Config().Mines)
+	return g.restartStopwatch()
+}
 
-	for i := 0; i < n; i++ {
-		x, y := coords[i].unwrap()
+func (g *game) restartStopwatch() tea.Cmd {
+	if g.stopwatch.Running() {
+		return g.stopwatch.Reset()
+	}
+	return tea.Batch(g.stopwatch.Reset(), g.stopwatch.Start())
+}
 
-		grid[y][x] = -1
+// move handles the outcome of a move on the board. Refused moves, like
+// flagging a revealed cell, are ignored. The first reveal of a no guess
+// board starts laying it out.
+func (g *game) move(err error) tea.Cmd {
+	var moveErr *engine.MoveError
+	if errors.As(err, &moveErr) && errors.Is(err, engine.ErrNotLaidOut) {
+		return g.layOut(engine.Coord{X: moveErr.X, Y: moveErr.Y})
+	}
+	g.message = ""
+	if err != nil && moveErr == nil {
+		g.message = err.Error()
+	}
+	if g.board.Over() {
+		return g.stopwatch.Stop()
+	}
+	return nil
+}
 
-		adjacent := []coord{
-			{x - 1, y - 1}, {x - 1, y}, {x - 1, y + 1},
-			{x, y - 1}, {x, y + 1},
-			{x + 1, y - 1}, {x + 1, y}, {x + 1, y + 1},
-		}
+// layoutMsg brings the layout of a no guess board, laid out away from the
+// update loop.
+type layoutMsg struct {
+	board  *engine.Board
+	layout *engine.Layout
+	err    error
+}
 
-		for _, mod := range adjacent {
-			mx, my := mod.unwrap()
-			if mx < 0 || mx > nCols-1 {
-				continue
-			}
-			if my < 0 || my > nRows-1 {
-				continue
-			}
-			if grid[my][mx] == -1 {
-				continue
-			}
-			grid[my][mx] += 1
-		}
+// layOut lays out the no guess board around its first reveal at c. Keys
+// keep working meanwhile, but the board is only opened once.
+func (g *game) layOut(c engine.Coord) tea.Cmd {
+	if g.layingOut {
+		return nil
 	}
+	g.layingOut = true
+	g.message = "laying out a board that needs no guessing..."
+	board := g.board
+	return func() tea.Msg {
+		layout, err := engine.Generate(board.Config(), c)
+		return layoutMsg{board, layout, err}
+	}
+}
 
-	return nil
+// opened opens the board with the layout laid out for it, unless a new
+// board was started meanwhile.
+func (g *game) opened(msg layoutMsg) tea.Cmd {
+	if msg.board != g.board {
+		return nil
+	}
+	g.layingOut = false
+	if msg.err != nil {
+		g.message = msg.err.Error() + ", try fewer mines"
+		return nil
+	}
+	return g.move(g.board.Open(msg.layout))
 }
 
-func show(grid [][]int, states [][]cellState, initX, initY int) {
-	// we'll go breadth first
-	queue := []coord{{initX, initY}}
-	nx, ny := len(grid[0]), len(grid)
+// prefixKeys start commands that take another key.
+var prefixKeys = map[string]bool{
+	"g": true,
+	"m": true,
+	"q": true,
+	"@": true,
+	"'": true,
+	"`": true,
+	"z": true,
+}
 
-	for len(queue) > 0 {
-		// lets go level by level
-		currentLen := len(queue)
+// nameKeys are the prefixes whose next key names a mark or register.
+var nameKeys = map[string]bool{
+	"m": true,
+	"q": true,
+	"@": true,
+	"'": true,
+	"`": true,
+}
 
-		for i := 0; i < currentLen; i++ {
-			x, y := queue[0].unwrap()
-			queue = queue[1:]
-			// here we see whether or not the value is 0
-			// if so this means that we can show all of
-			// the items around it
+// countDigit adds key to the pending count if it is a digit. A 0 only
+// counts after another digit.
+func (g *game) countDigit(key string) bool {
+	if !isDigit(key) {
+		return false
+	}
+	if key == "0" && g.count == "" {
+		return false
+	}
+	if len(g.count) < maxCountDigits {
+		g.count += key
+	}
+	return true
+}
 
-			states[y][x] = revealed
+const maxCountDigits = 4
 
-			if grid[y][x] != 0 {
-				// if it isn't 0 then move on to the next in the queue
-				continue
-			}
+func isDigit(key string) bool {
+	return len(key) == 1 && key[0] >= '0' && key[0] <= '9'
+}
 
-			adjacent := []coord{
-				{x - 1, y - 1}, {x - 1, y}, {x - 1, y + 1},
-				{x, y - 1}, {x, y + 1},
-				{x + 1, y - 1}, {x + 1, y}, {x + 1, y + 1},
-			}
+// takeCount returns the pending count, 1 when there is none, and clears it.
+func (g *game) takeCount() int {
+	n, err := strconv.Atoi(g.count)
+	g.count = ""
+	if err != nil {
+		return 1
+	}
+	return n
+}
 
-			for _, mod := range adjacent {
-				mx, my := mod.unwrap()
-				if my < 0 || my > ny-1 {
-					continue
-				}
-				if mx < 0 || mx > nx-1 {
-					continue
-				}
-				if states[my][mx] == hidden {
-					queue = append(queue, coord{mx, my})
-				}
-			}
+// step moves the cursor n cells in direction d, stopping at the edge of the
+// board.
+func (g *game) step(d engine.Coord, n int) {
+	g.direction = d
+	for i := 0; i < n; i++ {
+		next := engine.Coord{X: g.cursor.X + d.X, Y: g.cursor.Y + d.Y}
+		if !g.board.Inside(next.Unwrap()) {
+			break
 		}
+		g.cursor = next
 	}
 }
 
-type game struct {
-	model *model
-	// in each cell if it is a mine the int will be -1
-	// otherwise each will be the number of mines next to it
-	grid       [][]int
-	cellStates [][]cellState
-	cursor     coord
-	gameState  gameState
-	mode       gameMode
-	stopwatch  stopwatch.Model
-	flags      int
+// along makes a move on n cells, starting at the cursor and going the way
+// of the last motion: first on the cursor and rest on the cells after it.
+// The cells are undone together.
+func (g *game) along(n int, first, rest func(x, y int) error) tea.Cmd {
+	var err error
+	g.board.Batch(func() {
+		c := g.cursor
+		move := first
+		for i := 0; i < n && g.board.Inside(c.Unwrap()) && !g.board.Over(); i++ {
+			if i > 0 {
+				move = rest
+			}
+			if e := move(c.Unwrap()); err == nil {
+				err = e
+			}
+			c = engine.Coord{X: c.X + g.direction.X, Y: c.Y + g.direction.Y}
+		}
+	})
+	return g.move(err)
 }
 
-func NewGame(model *model) *game {
-	return &game{
-		model:      model,
-		grid:       make([][]int, 0),
-		cellStates: make([][]cellState, 0),
-		cursor:     coord{},
-		gameState:  pendingGame,
-		stopwatch:  stopwatch.New(),
-		flags:      0,
+// undo takes back the last move. Outside of practice mode a lost game stays
+// lost.
+func (g *game) undo() tea.Cmd {
+	if g.board.State() == engine.Lost && !g.practice {
+		g.message = "a lost game can only be taken back in practice mode"
+		return nil
+	}
+	over := g.board.Over()
+	if err := g.board.Undo(); err != nil {
+		g.message = err.Error()
+		return nil
 	}
+	g.usedUndo = true
+	g.message = ""
+	if over && !g.board.Over() {
+		return g.stopwatch.Start()
+	}
+	return nil
 }
 
-func (g *game) update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	x, y := g.cursor.unwrap()
-	switch msg := msg.(type) {
-	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c", "q":
-			return g.model, tea.Quit
-		case "h":
-			if x <= 0 {
-				break
-			}
-			g.cursor = coord{x - 1, y}
-		case "j":
-			if y >= len(g.grid)-1 {
-				break
-			}
-			g.cursor = coord{x, y + 1}
-		case "k":
-			if y <= 0 {
-				break
-			}
-			g.cursor = coord{x, y - 1}
-		case "l":
-			if x >= len(g.grid[0])-1 {
-				break
-			}
-			g.cursor = coord{x + 1, y}
-		case "x":
-			if g.cellStates[y][x] == revealed {
-				break
-			}
-			if g.cellStates[y][x] == flagged {
-				g.flags += 1
-			}
-			show(g.grid, g.cellStates, x, y)
-			g.gameState = evaluate(g.grid, g.cellStates)
-			if g.gameState == wonGame || g.gameState == lostGame {
-				return g.model, g.stopwatch.Stop()
-			}
-		case "d":
-			{
-				if g.cellStates[y][x] == hidden || g.cellStates[y][x] == flagged {
-					break
-				}
-				adjacent := []coord{
-					{x - 1, y - 1}, {x, y - 1}, {x + 1, y - 1},
-					{x - 1, y}, {x + 1, y},
-					{x - 1, y + 1}, {x, y + 1}, {x + 1, y + 1},
-				}
-				for _, a := range adjacent {
-					if a.y < 0 || a.y > len(g.cellStates)-1 {
-						continue
-					}
-					if a.x < 0 || a.x > len(g.cellStates[y])-1 {
-						continue
-					}
-					if g.cellStates[a.y][a.x] == hidden {
-						show(g.grid, g.cellStates, a.x, a.y)
-						g.gameState = evaluate(g.grid, g.cellStates)
-					}
-					if g.gameState == wonGame || g.gameState == lostGame {
-						return g.model, g.stopwatch.Stop()
-					}
-				}
-			}
-		case "f":
-			if g.cellStates[y][x] == revealed {
-				break
-			}
-			if g.cellStates[y][x] == flagged {
-				g.cellStates[y][x] = hidden
-				g.flags += 1
-				break
-			}
-			g.cellStates[y][x] = flagged
-			g.flags -= 1
-		case "r":
-			if g.mode == beginner {
-				g.setBeginner()
-			} else if g.mode == intermediate {
-				g.setIntermediate()
-			} else if g.mode == expert {
-				g.setExpert()
-			}
-			if g.stopwatch.Running() {
-				return g.model, g.stopwatch.Reset()
-			}
-			return g.model, tea.Batch(g.stopwatch.Reset(), g.stopwatch.Start())
-		case "w":
-			if g.gameState == wonGame {
-				g.model.current = g.model.saveMenu
-			}
-		}
+func (g *game) redo() tea.Cmd {
+	over := g.board.Over()
+	if err := g.board.Redo(); err != nil {
+		g.message = err.Error()
+		return nil
 	}
-	var cmd tea.Cmd
-	g.stopwatch, cmd = g.stopwatch.Update(msg)
-	return g.model, cmd
+	g.message = ""
+	if !over && g.board.Over() {
+		return g.stopwatch.Stop()
+	}
+	return nil
 }
 
 func (g *game) view() string {
 	b := strings.Builder{}
+	t := g.model.settings.theme()
+	g.follow()
 
-	width := len(g.grid[0])
-	space := strings.Repeat(" ", width/2*3-4)
-
-	digits := strconv.Itoa(g.flags)
-	if g.flags > 999 {
+	flags := g.board.FlagsLeft()
+	digits := strconv.Itoa(flags)
+	if flags > 999 {
 		digits = "999"
 	}
 	if len(digits) < 3 {
 		digits = strings.Repeat("0", 3-len(digits)) + digits
 	}
-
-	b.WriteString(digitsStyle.Render("\n" + digits))
-	b.WriteString(space)
-	if width%2 == 1 {
-		b.WriteString(" ")
-	}
-
-	switch g.gameState {
-	case wonGame:
-		b.WriteString("😎")
-	case lostGame:
-		b.WriteString("😵")
+	counter := t.digits.Render(digits)
+
+	var face string
+	switch g.board.State() {
+	case engine.Won:
+		face = g.model.settings.glyphs().won
+	case engine.Lost:
+		face = g.model.settings.glyphs().lost
 	default:
-		b.WriteString("🙂")
-	}
-
-	b.WriteString(space)
-	if width%2 == 1 {
-		b.WriteString("  ")
+		face = g.model.settings.glyphs().playing
 	}
 
 	elapsed := g.stopwatch.Elapsed()
@@ -397,50 +581,156 @@ func (g *game) view() string {
 	if len(digits) < 3 {
 		digits = strings.Repeat("0", 3-len(digits)) + digits
 	}
+	timer := t.digits.Render(digits)
 
-	b.WriteString(digitsStyle.Render(digits))
-	b.WriteString("\n\n")
-	for y := range g.cellStates {
-		for x := range g.cellStates[y] {
-			state := g.cellStates[y][x]
-			coordinate := coord{x, y}
-			b.WriteString(state.view(g.grid[y][x], coordinate == g.cursor))
-		}
-		b.WriteString("\n\n")
+	b.WriteString("\n" + g.viewHeader(counter, face, timer) + "\n\n")
+	board := g.viewBoard()
+	if g.showMinimap() {
+		board = lipgloss.JoinHorizontal(lipgloss.Top, strings.TrimSuffix(board, "\n"), g.minimap()) + "\n"
+	}
+	b.WriteString(board)
+	b.WriteString(g.seedLine() + "\n")
+	if hint := g.hint(); hint != "" {
+		b.WriteString("\n" + hint + "\n")
 	}
-	if g.gameState == wonGame {
-		b.WriteString("\nPress 'w' to save\n")
+	b.WriteString(g.statusLine() + "\n")
+	if line := g.commandLine(); line != "" {
+		b.WriteString(line + "\n")
 	}
 	return b.String()
 }
 
-func (g *game) setGrid(width, height, mines int) {
-	g.grid = make([][]int, height)
+// seedLine says what the board was laid out from and how it is played.
+func (g *game) seedLine() string {
+	line := "seed: " + strconv.FormatInt(g.board.Config().Seed, 10)
+	if first, ok := g.board.First(); ok {
+		line += ", opened at " + cellName(first)
+	}
+	if g.noGuess {
+		line += " (no guess)"
+	}
+	if g.practice {
+		line += " (practice)"
+	}
+	if g.usedUndo {
+		line += " (unranked, undo was used)"
+	}
+	return line
+}
 
-	for y := 0; y < height; y++ {
-		g.grid[y] = make([]int, width)
-		for x := 0; x < width; x++ {
-			g.grid[y][x] = 0
+// hint says what can be done once the game is over.
+func (g *game) hint() string {
+	switch g.board.State() {
+	case engine.Won:
+		return "Press 'w' to save"
+	case engine.Lost:
+		hint := "X marks a wrong flag. Look around, then press 'r' to play again"
+		if g.practice {
+			hint += " or 'u' to take it back"
 		}
+		return hint
 	}
+	return ""
+}
 
-	placeMines(g.grid, mines)
-
-	states := make([][]cellState, height)
+// commandLine is the last line, which works like vim's command line.
+func (g *game) commandLine() string {
+	switch {
+	case g.command.active():
+		return g.command.view()
+	case g.searchLine.active():
+		return g.searchLine.view()
+	case g.visual == charVisual:
+		return "-- VISUAL -- " + g.count + g.pending
+	case g.visual == blockVisual:
+		return "-- VISUAL BLOCK -- " + g.count + g.pending
+	case g.recording != "":
+		return "recording @" + g.recording + " " + g.typing()
+	case g.operator != "" || g.count != "" || g.pending != "":
+		return g.typing()
+	}
+	return g.message
+}
 
-	for y := 0; y < height; y++ {
-		states[y] = make([]cellState, width)
-		for x := 0; x < width; x++ {
-			states[y][x] = hidden
+// viewCell draws a cell. Once the game is lost every mine is shown, the one
+// that went off stands out and wrong flags are crossed out.
+func (g *game) viewCell(c engine.Coord) string {
+	t := g.model.settings.theme()
+	d := g.model.settings.density()
+	val, state := g.board.Cell(c.Unwrap())
+	lost := g.board.State() == engine.Lost
+	detonated, _ := g.board.Detonated()
+
+	var style lipgloss.Style
+	var content string
+	switch {
+	case lost && c == detonated:
+		style, content = t.detonated, "*"
+	case lost && val == engine.Mine && state != engine.Flagged:
+		style, content = t.mine, "*"
+	case lost && val != engine.Mine && state == engine.Flagged:
+		style, content = t.wrongFlag, "X"
+	case state == engine.Hidden:
+		style, content = t.hidden, " "
+	case state == engine.Flagged:
+		style, content = t.flagged, g.model.settings.glyphs().flag
+		if lipgloss.Width(content) > d.cell {
+			content = asciiGlyphs.flag
 		}
+	case val == engine.Mine:
+		style, content = t.detonated, "*"
+	default:
+		style, content = t.revealed[val], strconv.Itoa(val)
+	}
+	if g.pulsing(c) {
+		style = t.pulsing(style)
+	}
+	if g.highlighted(c) {
+		style = t.matched(style)
+	}
+	if g.selected(c) {
+		style = t.selected(style)
 	}
-	g.cellStates = states
-	g.gameState = playableGame
-	g.flags = mines // the same number of flags as mines
+	if c == g.cursor && lost && c == detonated {
+		style = t.focusedDetonated(style)
+	} else if c == g.cursor {
+		style = t.focused(style)
+	}
+	return style.Copy().Width(d.cell).Render(content)
+}
+
+func (g *game) setGrid(width, height, mines int) {
+	seed := g.fixedSeed
+	if seed == 0 {
+		seed = newSeed()
+	}
+	// mines are placed on the first reveal so the rule can keep it safe
+	board, err := engine.NewBoard(boardSize{width, height, mines}.config(g.rule, g.noGuess, seed))
+	if err != nil {
+		log.Fatal(err)
+	}
+	g.board = board
+	g.layingOut = false
+	g.message = ""
+	g.visual = noVisual
+	g.operator = ""
+	g.inMinimap = false
+	g.marks = map[byte]engine.Coord{}
+	g.jumps = nil
+	g.jumpIndex = 0
+	g.usedUndo = false
+	if !board.Inside(g.cursor.Unwrap()) {
+		g.cursor = engine.Coord{}
+	}
+	g.follow()
 }
 
-func (g *game) setMode(mode gameMode) {
+func (g *game) setMode(mode gameMode, rule engine.FirstReveal, noGuess, practice bool, seed int64) {
 	g.mode = mode
+	g.rule = rule
+	g.noGuess = noGuess
+	g.practice = practice
+	g.fixedSeed = seed
 	if mode == beginner {
 		g.setBeginner()
 	} else if mode == intermediate {
@@ -450,16 +740,27 @@ func (g *game) setMode(mode gameMode) {
 	}
 }
 
+func (g *game) size() boardSize {
+	c := g.board.Config()
+	return boardSize{c.Width, c.Height, c.Mines}
+}
+
 func (g *game) setBeginner() {
-	g.setGrid(9, 9, 10)
+	size := presets[beginner]
+	g.setGrid(size.width, size.height, size.mines)
 }
 
 func (g *game) setIntermediate() {
-	g.setGrid(16, 16, 40)
+	size := presets[intermediate]
+	g.setGrid(size.width, size.height, size.mines)
 }
 
 func (g *game) setExpert() {
-	g.setGrid(30, 16, 99)
+	size := presets[expert]
+	g.setGrid(size.width, size.height, size.mines)
 }
 
-// func (g *game) setCustom() {}
+func (g *game) setCustom(size boardSize) {
+	g.mode = custom
+	g.setGrid(size.width, size.height, size.mines)
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/ethanefung/minesweeper/engine"
+)
+
+// newTestGame starts a beginner game away from the player's configuration.
+func newTestGame(t *testing.T) *game {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	m := NewModel()
+	m.game.setMode(beginner, engine.SafeCell, false, false, 1)
+	m.current = m.game
+	return m.game
+}
+
+// typeKeys types keys on the board one at a time, timeoutKey standing for
+// waiting until an operator times out.
+func typeKeys(g *game, keys ...string) {
+	for _, key := range keys {
+		if key == timeoutKey {
+			g.timeout()
+			continue
+		}
+		g.update(keyMsg(key))
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/ethanefung/minesweeper/engine"
+)
+
+// The gutters number the rows left of the board and letter the columns
+// above it, so that counts can be read off rather than counted.
+
+// columnName letters columns the way spreadsheets do: A to Z, then AA, AB
+// and on.
+func columnName(x int) string {
+	name := ""
+	for x++; x > 0; x = (x - 1) / 26 {
+		name = string(rune('A'+(x-1)%26)) + name
+	}
+	return name
+}
+
+// cellName names a cell by its column letters and row number, as C5.
+func cellName(c engine.Coord) string {
+	return columnName(c.X) + strconv.Itoa(c.Y+1)
+}
+
+// gutterWidth is how wide the row numbers are, a space included, or 0 when
+// they are off.
+func (g *game) gutterWidth() int {
+	s := g.model.settings
+	if !s.number && !s.relativeNumber {
+		return 0
+	}
+	return len(strconv.Itoa(g.board.Height())) + 1
+}
+
+// rowNumber is the gutter of row y. relativenumber counts the rows from the
+// cursor's and, with number as well, the cursor's row shows its own number
+// as vim does.
+func (g *game) rowNumber(y int) string {
+	width := g.gutterWidth()
+	if width == 0 {
+		return ""
+	}
+	s := g.model.settings
+	t := s.theme()
+	if y == g.cursor.Y {
+		number := "0"
+		if s.number {
+			number = strconv.Itoa(y + 1)
+		}
+		if s.number && s.relativeNumber {
+			// the cursor's own number is aligned left, to stand out
+			return t.currentGutter.Render(number + strings.Repeat(" ", width-len(number)))
+		}
+		return t.currentGutter.Render(strings.Repeat(" ", width-1-len(number)) + number + " ")
+	}
+	number := strconv.Itoa(y + 1)
+	if s.relativeNumber {
+		distance := y - g.cursor.Y
+		if distance < 0 {
+			distance = -distance
+		}
+		number = strconv.Itoa(distance)
+	}
+	return t.gutter.Render(strings.Repeat(" ", width-1-len(number)) + number + " ")
+}
+
+// columnLetters is the line above the board naming its columns, or "" when
+// they are off.
+func (g *game) columnLetters() string {
+	if !g.model.settings.columnLetters {
+		return ""
+	}
+	t := g.model.settings.theme()
+	d := g.model.settings.density()
+	b := strings.Builder{}
+	b.WriteString(strings.Repeat(" ", g.gutterWidth()))
+	if d.bordered {
+		// over the line left of the first column
+		b.WriteString(" ")
+	}
+	for x := g.left; x < g.left+g.viewColumns(); x++ {
+		style := t.gutter
+		if x == g.cursor.X {
+			style = t.currentGutter
+		}
+		name := columnName(x)
+		if len(name) > d.cell {
+			// compact columns only have room for the last letter
+			name = name[len(name)-d.cell:]
+		}
+		b.WriteString(style.Copy().Width(d.cell).Align(lipgloss.Center).Render(name))
+		if d.bordered {
+			b.WriteString(" ")
+		}
+	}
+	return b.String() + "\n"
+}
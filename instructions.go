@@ -3,24 +3,26 @@ package main
 import (
 	"strings"
 
+	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
 type instructions struct {
 	model *model
+	keys  keymap
 }
 
 func NewInstructions(m *model) *instructions {
-	return &instructions{m}
+	return &instructions{m, m.keys.menu}
 }
 
 func (m *instructions) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c", "q":
+		switch {
+		case key.Matches(msg, m.keys.Quit):
 			return m.model, tea.Quit
-		case "b":
+		case key.Matches(msg, m.keys.Back):
 			m.model.current = m.model.mainMenu
 		}
 	}
@@ -33,17 +35,34 @@ func (i *instructions) view() string {
 	b.WriteString("The goal of minesweeper is to reveal all the cells in the grid that do not have mines.\n\n")
 
 	b.WriteString("The first thing you'll notice in game is there are no arrow keys to navigate the cursor!\nThis is an intentional choice.\n")
-	b.WriteString("Instead focus on moving the cursor using 'h','j','k', and 'l'.\n\n")
+	b.WriteString("Instead focus on moving the cursor using 'h','j','k', and 'l'.\n")
+	b.WriteString("Like in vim, a count before a motion repeats it: '5j' moves down five rows.\n")
+	b.WriteString("'0', '^', '$', 'gg', 'G', 'H', 'M' and 'L' work as well, and 'w', 'b' and 'e' jump\n")
+	b.WriteString("between runs of hidden and revealed cells the way they jump between words.\n")
+	b.WriteString("':set number', ':set relativenumber' and ':set columnletters' label the rows and columns.\n")
+	b.WriteString("Boards bigger than the terminal scroll with 'ctrl+e', 'ctrl+y', 'ctrl+d', 'ctrl+u', 'zz', 'zt' and 'zb'.\n")
+	b.WriteString("':set density=compact', 'tight' or 'bordered' draws the board tighter or with lines around its cells.\n")
+	b.WriteString("':set minimap' shows the whole board beside it, and 'ctrl+w' then 'hjkl' and 'enter' jumps around it.\n")
+	b.WriteString("Marks ('ma', then ''a' or '`a') and the jumplist ('ctrl+o' and 'ctrl+i') help with two fronts.\n\n")
 
 	b.WriteString("Press 'x' and mimic removing a character to select and reveal a cell.\n")
-	b.WriteString("Press 'd' on a revealed number to select and reveal all non-flagged adjacent cells\n          (mimicking deleting a word).\n")
-	b.WriteString("Press 'q' at any point (in game or not) to terminate the program.\n\n")
+	b.WriteString("Press 'd' on a revealed number to select and reveal all non-flagged adjacent cells\n          (mimicking deleting a word). It only works once the number is surrounded by as many flags.\n")
+	b.WriteString("Press '/' to search: '/3' finds threes, '/?' the frontier and '/f' flags. 'n' and 'N' go through them.\n")
+	b.WriteString("Press 'v' (or 'ctrl+v' for a block) to select cells, then 'f', 'x' or 'd' to act on all of them.\n")
+	b.WriteString("'x', 'd' and 'f' take a motion like vim's operators: 'f3l' flags this cell and the three to\n          its right, 'xj' reveals this cell and the one below, 'daw' chords the cursor's\n          neighbourhood and 'fip' its hidden region.\n")
+	b.WriteString("Press '.' to repeat the last 'x', 'd' or 'f' where the cursor is.\n")
+	b.WriteString("Press 'u' to undo and 'ctrl+r' to redo, though a game that used undo is saved unranked.\n")
+	b.WriteString("Press ':' for a command line: ':new expert', ':custom 40 20 150', ':seed 1234', ':restart',\n          ':set aggressivechord', ':w' and ':q'. Tab completes and up and down go through history.\n")
+	b.WriteString("Press 'qa' to record a macro into register a, 'q' to stop and '@a' to play it back ('@@' plays it again).\n")
+	b.WriteString("Press 'q' (in a game, 'q' on its own for a moment), ':q' or 'ctrl+c' to terminate the program.\n\n")
 
 	b.WriteString("Here are some commands you can issue that does not mimic vim.\n\n")
 	b.WriteString("You can toggle flags on unrevealed cells by pressing 'f'.\n")
 	b.WriteString("At any point during play you can press 'r' to reset the game.\n\n")
 
-	b.WriteString("Now...press 'b', to go to the main menu and get sweeping!\n")
+	b.WriteString("Every key can be changed in keys.json, in the vim-minesweeper folder of your config directory.\n\n")
+
+	b.WriteString("Now...press '" + i.keys.Back.Help().Key + "', to go to the main menu and get sweeping!\n")
 
 	return b.String()
 }
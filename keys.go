@@ -0,0 +1,425 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+
+	"github.com/charmbracelet/bubbles/key"
+)
+
+// The keys of every screen can be changed in keys.json, in the config
+// directory. Each section names the bindings to change and the keys they
+// take instead of their own, for example
+//
+//	{
+//		"scheme": "arrows",
+//		"menu": {"up": ["k", "i"]},
+//		"game": {"left": ["j"], "down": ["k"], "up": ["i"], "right": ["l"]}
+//	}
+//
+// The arrows scheme adds the arrow keys to the moves of every screen.
+
+const keysFile = "keys.json"
+
+// keymap is the keys of the menus: the main, play and settings menus, the
+// scores and the instructions.
+type keymap struct {
+	Up       key.Binding
+	Down     key.Binding
+	Select   key.Binding
+	Rule     key.Binding
+	NoGuess  key.Binding
+	Practice key.Binding
+	Back     key.Binding
+	Cancel   key.Binding
+	Next     key.Binding
+	Prev     key.Binding
+	Quit     key.Binding
+}
+
+func (k keymap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Up, k.Down, k.Select, k.Rule, k.NoGuess, k.Practice, k.Back, k.Quit}
+}
+
+func (k keymap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Up, k.Down},
+		{k.Select, k.Rule, k.NoGuess, k.Practice, k.Back},
+		{k.Quit},
+	}
+}
+
+// named lists the bindings by the names keys.json knows them by.
+func (k *keymap) named() map[string]*key.Binding {
+	return map[string]*key.Binding{
+		"up":       &k.Up,
+		"down":     &k.Down,
+		"select":   &k.Select,
+		"rule":     &k.Rule,
+		"noguess":  &k.NoGuess,
+		"practice": &k.Practice,
+		"back":     &k.Back,
+		"cancel":   &k.Cancel,
+		"next":     &k.Next,
+		"prev":     &k.Prev,
+		"quit":     &k.Quit,
+	}
+}
+
+// menuGroups are the menu bindings in use at the same time, the menus
+// themselves and the fields of the custom board and seed entries.
+var menuGroups = [][]string{
+	{"up", "down", "select", "rule", "noguess", "practice", "back", "quit"},
+	{"cancel", "next", "prev", "select"},
+}
+
+var menuKeys = keymap{
+	Up: key.NewBinding(
+		key.WithKeys("k", "up"),
+		key.WithHelp("↑/k", "move up"),
+	),
+	Down: key.NewBinding(
+		key.WithKeys("j", "down"),
+		key.WithHelp("↓/j", "move down"),
+	),
+	Select: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "select option"),
+	),
+	Rule: key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "change first reveal rule"),
+	),
+	NoGuess: key.NewBinding(
+		key.WithKeys("n"),
+		key.WithHelp("n", "toggle no guess boards"),
+	),
+	Practice: key.NewBinding(
+		key.WithKeys("p"),
+		key.WithHelp("p", "toggle practice mode"),
+	),
+	Back: key.NewBinding(
+		key.WithKeys("b"),
+		key.WithHelp("b", "previous menu"),
+	),
+	Cancel: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "stop editing"),
+	),
+	Next: key.NewBinding(
+		key.WithKeys("tab", "down"),
+		key.WithHelp("tab", "next field"),
+	),
+	Prev: key.NewBinding(
+		key.WithKeys("shift+tab", "up"),
+		key.WithHelp("shift+tab", "previous field"),
+	),
+	Quit: key.NewBinding(
+		key.WithKeys("q", "ctrl+c"),
+		key.WithHelp("q", "quit"),
+	),
+}
+
+// saveKeymap is the keys of the screen that saves a won game.
+type saveKeymap struct {
+	Left     key.Binding
+	Right    key.Binding
+	Next     key.Binding
+	Previous key.Binding
+	Yes      key.Binding
+	No       key.Binding
+	Quit     key.Binding
+}
+
+func (k *saveKeymap) named() map[string]*key.Binding {
+	return map[string]*key.Binding{
+		"left":     &k.Left,
+		"right":    &k.Right,
+		"next":     &k.Next,
+		"previous": &k.Previous,
+		"yes":      &k.Yes,
+		"no":       &k.No,
+		"quit":     &k.Quit,
+	}
+}
+
+var saveKeys = saveKeymap{
+	Left: key.NewBinding(
+		key.WithKeys("h"),
+		key.WithHelp("h", "previous initial"),
+	),
+	Right: key.NewBinding(
+		key.WithKeys("l"),
+		key.WithHelp("l", "next initial"),
+	),
+	Next: key.NewBinding(
+		key.WithKeys("j"),
+		key.WithHelp("j", "next letter"),
+	),
+	Previous: key.NewBinding(
+		key.WithKeys("k"),
+		key.WithHelp("k", "previous letter"),
+	),
+	Yes: key.NewBinding(
+		key.WithKeys("y"),
+		key.WithHelp("y", "save"),
+	),
+	No: key.NewBinding(
+		key.WithKeys("n"),
+		key.WithHelp("n", "don't save"),
+	),
+	Quit: key.NewBinding(
+		key.WithKeys("q", "ctrl+c"),
+		key.WithHelp("q", "quit"),
+	),
+}
+
+// gameKey is a command of the game. The game reads keys as vim does, a
+// count, an operator and a motion making up a command, so rather than
+// matching bindings itself it has every key pressed translated to the key
+// the command is known by.
+type gameKey struct {
+	name    string
+	command string
+	binding key.Binding
+}
+
+// gameKeymap is the keys of the game. Counts are always typed with the
+// digits, and the key after m, ', `, q or @ names a mark or register.
+type gameKeymap []gameKey
+
+func newGameKey(name, command, help string) gameKey {
+	return gameKey{name, command, key.NewBinding(key.WithKeys(command), key.WithHelp(command, help))}
+}
+
+func defaultGameKeys() gameKeymap {
+	return gameKeymap{
+		newGameKey("left", "h", "move left"),
+		newGameKey("down", "j", "move down"),
+		newGameKey("up", "k", "move up"),
+		newGameKey("right", "l", "move right"),
+		newGameKey("scrolldown", "ctrl+e", "scroll down a row"),
+		newGameKey("scrollup", "ctrl+y", "scroll up a row"),
+		newGameKey("halfdown", "ctrl+d", "scroll down half a screen"),
+		newGameKey("halfup", "ctrl+u", "scroll up half a screen"),
+		newGameKey("scroll", "z", "start zz, zt and zb"),
+		newGameKey("minimap", "ctrl+w", "move into the minimap"),
+		newGameKey("start", "0", "start of the row"),
+		newGameKey("firsthidden", "^", "first hidden cell of the row"),
+		newGameKey("end", "$", "end of the row"),
+		newGameKey("prefix", "g", "start gg"),
+		newGameKey("bottom", "G", "last row"),
+		newGameKey("high", "H", "top row"),
+		newGameKey("middle", "M", "middle row"),
+		newGameKey("low", "L", "bottom row"),
+		newGameKey("word", "w", "next run of cells, or save a won game"),
+		newGameKey("back", "b", "previous run of cells"),
+		newGameKey("wordend", "e", "end of the run of cells"),
+		newGameKey("reveal", "x", "reveal"),
+		newGameKey("chord", "d", "chord"),
+		newGameKey("flag", "f", "flag"),
+		newGameKey("inner", "i", "inner text object"),
+		newGameKey("around", "a", "around text object"),
+		newGameKey("repeat", ".", "repeat the last change"),
+		newGameKey("undo", "u", "undo"),
+		newGameKey("redo", "ctrl+r", "redo"),
+		newGameKey("restart", "r", "new board"),
+		newGameKey("command", ":", "command line"),
+		newGameKey("search", "/", "search"),
+		newGameKey("searchnext", "n", "next match"),
+		newGameKey("searchprevious", "N", "previous match"),
+		newGameKey("visual", "v", "visual mode"),
+		newGameKey("visualblock", "ctrl+v", "visual block mode"),
+		newGameKey("other", "o", "other end of the selection"),
+		newGameKey("escape", "esc", "leave visual mode or cancel"),
+		newGameKey("mark", "m", "set a mark"),
+		newGameKey("markrow", "'", "go to a mark's row"),
+		newGameKey("markcell", "`", "go to a mark"),
+		newGameKey("jumpback", "ctrl+o", "back through the jumplist"),
+		newGameKey("jumpforward", "tab", "forward through the jumplist"),
+		newGameKey("record", "q", "record a macro"),
+		newGameKey("play", "@", "play a macro"),
+		newGameKey("quit", "ctrl+c", "quit"),
+	}
+}
+
+func (k gameKeymap) named() map[string]*key.Binding {
+	named := map[string]*key.Binding{}
+	for i := range k {
+		named[k[i].name] = &k[i].binding
+	}
+	return named
+}
+
+// translate finds the command typed with key. A key that was bound to a
+// command until the command was bound elsewhere does nothing, and any
+// other key is left as it is.
+func (k gameKeymap) translate(pressed string) string {
+	for _, gk := range k {
+		if !gk.binding.Enabled() {
+			continue
+		}
+		for _, bound := range gk.binding.Keys() {
+			if bound == pressed {
+				return gk.command
+			}
+		}
+	}
+	for _, gk := range k {
+		if gk.command == pressed {
+			return ""
+		}
+	}
+	return pressed
+}
+
+// keyBindings are the keys of every screen.
+type keyBindings struct {
+	menu keymap
+	save saveKeymap
+	game gameKeymap
+}
+
+// keyFile is what keys.json holds.
+type keyFile struct {
+	Scheme string              `json:"scheme"`
+	Menu   map[string][]string `json:"menu"`
+	Save   map[string][]string `json:"save"`
+	Game   map[string][]string `json:"game"`
+}
+
+// arrowKeys are what the arrows scheme adds to each screen's moves.
+var arrowKeys = map[string]string{
+	"left":     "left",
+	"right":    "right",
+	"up":       "up",
+	"down":     "down",
+	"next":     "down",
+	"previous": "up",
+}
+
+// NewKeyBindings loads keys.json over the default keys. When it cannot be
+// read or binds one key to two things the defaults are used instead and the
+// error says why.
+func NewKeyBindings() (*keyBindings, error) {
+	defaults := func() *keyBindings {
+		return &keyBindings{menuKeys, saveKeys, defaultGameKeys()}
+	}
+	path, err := configPath(keysFile)
+	if err != nil {
+		return defaults(), fmt.Errorf("%s was not loaded: %w", keysFile, err)
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return defaults(), nil
+	}
+	if err != nil {
+		return defaults(), fmt.Errorf("%s was not loaded: %w", keysFile, err)
+	}
+	k := defaults()
+	if err := k.load(data); err != nil {
+		return defaults(), fmt.Errorf("%s was not loaded, the default keys are used: %w", path, err)
+	}
+	return k, nil
+}
+
+func (k *keyBindings) load(data []byte) error {
+	var file keyFile
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&file); err != nil {
+		return err
+	}
+
+	sections := []struct {
+		name     string
+		bindings map[string]*key.Binding
+		changes  map[string][]string
+		// groups are the bindings in use at the same time
+		groups [][]string
+	}{
+		{"menu", k.menu.named(), file.Menu, menuGroups},
+		{"save", k.save.named(), file.Save, nil},
+		{"game", k.game.named(), file.Game, nil},
+	}
+	for _, s := range sections {
+		for name, keys := range s.changes {
+			b, ok := s.bindings[name]
+			if !ok {
+				return fmt.Errorf("no %s key is called %q", s.name, name)
+			}
+			rebind(b, keys...)
+		}
+		if file.Scheme == "arrows" {
+			for name, arrow := range arrowScheme(s.name) {
+				if b, ok := s.bindings[name]; ok {
+					b.SetKeys(append(b.Keys(), arrow)...)
+				}
+			}
+		} else if file.Scheme != "" {
+			return fmt.Errorf("unknown scheme %q", file.Scheme)
+		}
+		groups := s.groups
+		if groups == nil {
+			all := []string{}
+			for name := range s.bindings {
+				all = append(all, name)
+			}
+			sort.Strings(all)
+			groups = [][]string{all}
+		}
+		for _, group := range groups {
+			if err := conflicts(s.name, s.bindings, group); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// arrowScheme is what the arrows scheme adds to a section.
+func arrowScheme(section string) map[string]string {
+	scheme := map[string]string{}
+	for name, arrow := range arrowKeys {
+		if section == "menu" && (name == "next" || name == "previous") {
+			// the fields already move with the arrows
+			continue
+		}
+		scheme[name] = arrow
+	}
+	return scheme
+}
+
+// rebind gives a binding new keys, its help showing the first of them. No
+// keys at all turns the binding off.
+func rebind(b *key.Binding, keys ...string) {
+	if len(keys) == 0 {
+		b.SetEnabled(false)
+		return
+	}
+	b.SetKeys(keys...)
+	b.SetHelp(keys[0], b.Help().Desc)
+}
+
+// conflicts reports a key bound to two of the bindings named in group.
+func conflicts(section string, bindings map[string]*key.Binding, group []string) error {
+	bound := map[string]string{}
+	for _, name := range group {
+		b := bindings[name]
+		if !b.Enabled() {
+			continue
+		}
+		for _, pressed := range b.Keys() {
+			if other, ok := bound[pressed]; ok && other != name {
+				return fmt.Errorf("%q is bound to both %s and %s in the %s keys", pressed, other, name, section)
+			}
+			bound[pressed] = name
+		}
+	}
+	return nil
+}
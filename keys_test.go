@@ -0,0 +1,175 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/ethanefung/minesweeper/engine"
+)
+
+func TestRebound(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		keys []string
+		want engine.Coord
+	}{
+		{
+			name: "gg",
+			keys: []string{"G", "l", "g", "g"},
+			want: engine.Coord{X: 1, Y: 0},
+		},
+		{
+			name: "gg with the prefix rebound",
+			file: `{"game": {"prefix": ["t"]}}`,
+			keys: []string{"G", "l", "t", "t"},
+			want: engine.Coord{X: 1, Y: 0},
+		},
+		{
+			name: "a mark named after a rebound key",
+			file: `{"game": {"down": ["n"], "searchnext": ["j"]}}`,
+			keys: []string{"m", "n", "n", "n", "`", "n"},
+			want: engine.Coord{X: 0, Y: 0},
+		},
+		{
+			name: "moving with a rebound key",
+			file: `{"game": {"down": ["n"], "searchnext": ["j"]}}`,
+			keys: []string{"2", "n"},
+			want: engine.Coord{X: 0, Y: 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newTestGame(t)
+			if tt.file != "" {
+				if err := g.model.keys.load([]byte(tt.file)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			typeKeys(g, tt.keys...)
+			if g.cursor != tt.want {
+				t.Errorf("cursor at %v, want %v", g.cursor, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		// err is part of the error load returns, "" for none
+		err string
+	}{
+		{name: "no changes", file: `{}`},
+		{
+			name: "menu keys in use at once",
+			file: `{"menu": {"noguess": ["j"]}}`,
+			err:  `"j" is bound to both`,
+		},
+		{
+			name: "menu keys in use at different times",
+			file: `{"menu": {"cancel": ["b"]}}`,
+		},
+		{
+			name: "a key shared by both menu groups",
+			file: `{"menu": {"select": ["enter", "space"]}}`,
+		},
+		{
+			name: "game keys",
+			file: `{"game": {"flag": ["x"]}}`,
+			err:  `"x" is bound to both flag and reveal in the game keys`,
+		},
+		{
+			name: "game keys swapped",
+			file: `{"game": {"flag": ["x"], "reveal": ["f"]}}`,
+		},
+		{
+			name: "save keys",
+			file: `{"save": {"yes": ["n"]}}`,
+			err:  `in the save keys`,
+		},
+		{
+			name: "a binding turned off",
+			file: `{"game": {"reveal": [], "flag": ["x"]}}`,
+		},
+		{
+			name: "an unknown binding",
+			file: `{"game": {"fly": ["y"]}}`,
+			err:  `no game key is called "fly"`,
+		},
+		{
+			name: "an unknown section",
+			file: `{"games": {}}`,
+			err:  `unknown field "games"`,
+		},
+		{
+			name: "an unknown scheme",
+			file: `{"scheme": "wasd"}`,
+			err:  `unknown scheme "wasd"`,
+		},
+		{
+			name: "the arrows scheme",
+			file: `{"scheme": "arrows"}`,
+		},
+		{
+			name: "a key the arrows scheme takes",
+			file: `{"scheme": "arrows", "game": {"flag": ["left"]}}`,
+			err:  `"left" is bound to both`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := &keyBindings{menuKeys, saveKeys, defaultGameKeys()}
+			err := k.load([]byte(tt.file))
+			if tt.err == "" && err != nil {
+				t.Errorf("load returned %v", err)
+			}
+			if tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+				t.Errorf("load returned %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestArrowScheme(t *testing.T) {
+	k := &keyBindings{menuKeys, saveKeys, defaultGameKeys()}
+	if err := k.load([]byte(`{"scheme": "arrows", "game": {"left": ["s"]}}`)); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		binding *key.Binding
+		want    []string
+	}{
+		{"menu up", &k.menu.Up, []string{"k", "up"}},
+		{"menu next field", &k.menu.Next, []string{"tab", "down"}},
+		{"save left", &k.save.Left, []string{"h", "left"}},
+		{"save next letter", &k.save.Next, []string{"j", "down"}},
+		{"game left", k.game.named()["left"], []string{"s", "left"}},
+		{"game flag", k.game.named()["flag"], []string{"f"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys := map[string]bool{}
+			for _, pressed := range tt.binding.Keys() {
+				keys[pressed] = true
+			}
+			for _, pressed := range tt.want {
+				if !keys[pressed] {
+					t.Errorf("keys are %q, want %q among them", tt.binding.Keys(), pressed)
+				}
+			}
+			if len(keys) != len(tt.want) {
+				t.Errorf("keys are %q, want %q", tt.binding.Keys(), tt.want)
+			}
+		})
+	}
+	if got := k.game.translate("left"); got != "h" {
+		t.Errorf("left is translated to %q, want h", got)
+	}
+	if got := k.game.translate("h"); got != "" {
+		t.Errorf("h is translated to %q once left is rebound", got)
+	}
+}
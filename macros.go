@@ -0,0 +1,181 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// macros are the keys recorded into each register with q{a-z}. They are kept
+// in the config directory so they outlive a game.
+type macros struct {
+	registers map[string][]string
+	// last is the register @@ plays
+	last string
+}
+
+const macrosFile = "macros.json"
+
+// maxReplayDepth and maxReplayKeys stop a macro that plays itself from
+// playing forever, one that plays itself twice from doubling at every level
+const (
+	maxReplayDepth = 100
+	maxReplayKeys  = 10000
+)
+
+// NewMacros loads the macros kept in the config directory. Without them the
+// registers start out empty and the error says why.
+func NewMacros() (*macros, error) {
+	m := &macros{registers: map[string][]string{}}
+	path, err := configPath(macrosFile)
+	if err != nil {
+		return m, fmt.Errorf("macros were not loaded: %w", err)
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return m, fmt.Errorf("macros were not loaded: %w", err)
+	}
+	registers := map[string][]string{}
+	if err := json.Unmarshal(data, &registers); err != nil {
+		return m, fmt.Errorf("macros were not loaded from %s: %w", path, err)
+	}
+	m.registers = registers
+	return m, nil
+}
+
+func (m *macros) save() error {
+	path, err := configPath(macrosFile)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(m.registers, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// keyTypes finds a key from the name bubbletea gives it, like "ctrl+r".
+var keyTypes = func() map[string]tea.KeyType {
+	types := map[string]tea.KeyType{}
+	// control keys are 0 to 127 and the other named keys are negative
+	for t := tea.KeyType(-128); t <= 127; t++ {
+		if name := t.String(); name != "" && t != tea.KeyRunes {
+			types[name] = t
+		}
+	}
+	return types
+}()
+
+// keyMsg turns the name of a key back into the message typing it sends.
+func keyMsg(name string) tea.KeyMsg {
+	if name == " " {
+		return tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(name)}
+	}
+	if t, ok := keyTypes[name]; ok {
+		return tea.KeyMsg{Type: t}
+	}
+	alt := false
+	if len(name) > len("alt+") && name[:len("alt+")] == "alt+" {
+		alt, name = true, name[len("alt+"):]
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(name), Alt: alt}
+}
+
+// updateMacro handles q{a-z}, @{a-z} and @@, reporting whether key was one
+// of them.
+func (g *game) updateMacro(key string, n int) (tea.Cmd, bool) {
+	if len(key) != 2 || (key[0] != 'q' && key[0] != '@') {
+		return nil, false
+	}
+	register := key[1:]
+	if key == "@@" {
+		register = g.model.macros.last
+	} else if !isMarkName(key[1]) {
+		return nil, false
+	}
+	if key[0] == 'q' {
+		g.recording = register
+		g.recorded = nil
+		return nil, true
+	}
+	return g.play(register, n), true
+}
+
+type quitTimeoutMsg struct {
+	id int
+}
+
+// waitToQuit quits the game if no register follows q within timeoutlen,
+// as q did before it recorded macros.
+func (g *game) waitToQuit() tea.Cmd {
+	g.quitID++
+	id := g.quitID
+	wait := time.Duration(g.model.settings.timeoutlen) * time.Millisecond
+	return tea.Tick(wait, func(time.Time) tea.Msg {
+		return quitTimeoutMsg{id}
+	})
+}
+
+// stopRecording keeps the keys recorded in their register, all but the q
+// that stopped it.
+func (g *game) stopRecording() {
+	keys := g.recorded
+	if len(keys) > 0 {
+		keys = keys[:len(keys)-1]
+	}
+	g.model.macros.registers[g.recording] = keys
+	g.recording = ""
+	g.recorded = nil
+	if err := g.model.macros.save(); err != nil {
+		g.message = "could not save macros: " + err.Error()
+	}
+}
+
+// play types the keys in a register n times. The changes they make are
+// kept for '.' as they are typed, so '.' afterwards repeats the last of
+// them.
+func (g *game) play(register string, n int) tea.Cmd {
+	keys, ok := g.model.macros.registers[register]
+	if !ok || register == "" {
+		g.message = "nothing recorded in @" + register
+		return nil
+	}
+	if g.replaying == 0 {
+		g.replayed = 0
+	}
+	if g.replaying >= maxReplayDepth || g.replayed >= maxReplayKeys {
+		return nil
+	}
+	g.model.macros.last = register
+	g.replaying++
+	defer func() { g.replaying-- }()
+	// the keys that played the macro are no change of their own
+	g.typed = nil
+	cmds := []tea.Cmd{}
+	for i := 0; i < n; i++ {
+		for _, key := range keys {
+			if g.replayed++; g.replayed > maxReplayKeys {
+				g.message = "@" + register + " stopped after " + strconv.Itoa(maxReplayKeys) + " keys"
+				return tea.Batch(cmds...)
+			}
+			if key == timeoutKey {
+				g.timeout()
+				continue
+			}
+			_, cmd := g.update(keyMsg(key))
+			cmds = append(cmds, cmd)
+		}
+	}
+	g.changed = false
+	return tea.Batch(cmds...)
+}
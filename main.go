@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"log"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -18,33 +19,79 @@ type model struct {
 	game         *game
 	saveMenu     *saveMenu
 	scores       *scores
+	settingsMenu *settingsMenu
+	settings     *settings
+	macros       *macros
+	keys         *keyBindings
 	current      current
+	// problems are what went wrong loading the configuration, shown under
+	// the main menu. The defaults are used instead.
+	problems []string
+	// width and height are the terminal's, 0 until the first
+	// tea.WindowSizeMsg
+	width, height int
 }
 
-func NewModel() model {
+func NewModel() *model {
 	m := new(model)
+	for _, err := range loadThemes() {
+		m.report(err)
+	}
+	var err error
+	m.settings, err = NewSettings()
+	m.report(err)
+	m.keys, err = NewKeyBindings()
+	m.report(err)
 	m.game = NewGame(m)
 	m.playMenu = NewPlayMenu(m)
 	m.mainMenu = NewMainMenu(m)
 	m.instructions = NewInstructions(m)
 	m.saveMenu = NewSaveMenu(m)
 	m.scores = NewScores(m)
+	m.macros, err = NewMacros()
+	m.report(err)
+	m.settingsMenu = NewSettingsMenu(m)
 	m.current = m.mainMenu
-	return *m
+	return m
+}
+
+// report keeps a problem loading the configuration to show it.
+func (m *model) report(err error) {
+	if err != nil {
+		m.problems = append(m.problems, err.Error())
+	}
 }
 
-func (m model) Init() tea.Cmd {
+func (m *model) Init() tea.Cmd {
 	return nil
 }
-func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.WindowSizeMsg); ok {
+		m.width, m.height = msg.Width, msg.Height
+	}
 	return m.current.update(msg)
 }
-func (m model) View() string {
+func (m *model) View() string {
 	return m.current.view()
 }
 
 func main() {
-	program := tea.NewProgram(NewModel())
+	seed := flag.Int64("seed", 0, "play boards generated from this seed (0 picks a random seed)")
+	ascii := flag.Bool("ascii", false, "draw the board without emoji (or set "+asciiEnv+")")
+	theme := flag.String("theme", "", "the theme to draw with, such as light or colourblind-dark (or set "+themeEnv+")")
+	flag.Parse()
+
+	m := NewModel()
+	m.playMenu.seed = *seed
+	if *ascii {
+		m.settings.ascii = true
+	}
+	if *theme != "" {
+		if _, err := m.settings.set("theme=" + *theme); err != nil {
+			log.Fatal(err)
+		}
+	}
+	program := tea.NewProgram(m)
 	if err := program.Start(); err != nil {
 		log.Fatalf("Booting Error: %v\n", err.Error())
 	}
@@ -4,9 +4,10 @@ import (
 	"fmt"
 	"io"
 
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/lipgloss"
 )
 
 type mainMenu struct {
@@ -14,19 +15,17 @@ type mainMenu struct {
 	cursor  int
 	list    list.Model
 	message tea.Msg
+	keys    keymap
 }
 
-var (
-	mainMenuStyle     = lipgloss.NewStyle().Margin(1, 2)
-	itemStyle         = lipgloss.NewStyle().PaddingLeft(4)
-	selectedItemStyle = lipgloss.NewStyle().PaddingLeft(4).Foreground(lipgloss.Color("170"))
-)
-
 type item string
 
 func (i item) FilterValue() string { return string(i) }
 
-type delegate struct{}
+// delegate draws the items of the menu in the settings' theme.
+type delegate struct {
+	settings *settings
+}
 
 func (d delegate) Height() int                               { return 1 }
 func (d delegate) Spacing() int                              { return 0 }
@@ -39,10 +38,11 @@ func (d delegate) Render(w io.Writer, m list.Model, index int, listItem list.Ite
 
 	str := fmt.Sprintf("  %s", i)
 
-	fn := itemStyle.Render
+	t := d.settings.theme()
+	fn := t.item.Render
 	if index == m.Index() {
 		fn = func(s string) string {
-			return selectedItemStyle.Render("> " + s[2:])
+			return t.selectedItem.Render("> " + s[2:])
 		}
 	}
 
@@ -54,12 +54,15 @@ func NewMainMenu(m *model) *mainMenu {
 		item("Play"),
 		item("How to play"),
 		item("Scores"),
+		item("Settings"),
 	}
-	list := list.New(items, delegate{}, 20, 14)
+	list := list.New(items, delegate{m.settings}, 20, 14)
 	list.SetShowStatusBar(false)
 	list.SetFilteringEnabled(false)
+	// the list's help would show its own keys rather than the menu's
+	list.SetShowHelp(false)
 	list.Title = "Welcome to Vim-Minesweeper"
-	return &mainMenu{m, 0, list, nil}
+	return &mainMenu{m, 0, list, nil, m.keys.menu}
 }
 
 func (m *mainMenu) update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -68,10 +71,14 @@ func (m *mainMenu) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.list.SetWidth(msg.Width)
 		return m.model, nil
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c", "q":
+		switch {
+		case key.Matches(msg, m.keys.Quit):
 			return m.model, tea.Quit
-		case "enter":
+		case key.Matches(msg, m.keys.Up):
+			m.list.CursorUp()
+		case key.Matches(msg, m.keys.Down):
+			m.list.CursorDown()
+		case key.Matches(msg, m.keys.Select):
 			switch m.list.SelectedItem().FilterValue() {
 			case "Play":
 				m.model.current = m.model.playMenu
@@ -79,8 +86,12 @@ func (m *mainMenu) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.model.current = m.model.instructions
 			case "Scores":
 				m.model.current = m.model.scores
+			case "Settings":
+				m.model.current = m.model.settingsMenu
 			}
 		}
+		// the list's own keys are left out for the menu's
+		return m.model, nil
 	}
 	var cmd tea.Cmd
 	m.list, cmd = m.list.Update(msg)
@@ -88,5 +99,10 @@ func (m *mainMenu) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m *mainMenu) view() string {
-	return mainMenuStyle.Render(m.list.View())
+	k := m.keys
+	keys := help.New().ShortHelpView([]key.Binding{k.Up, k.Down, k.Select, k.Quit})
+	for _, problem := range m.model.problems {
+		keys += "\n" + problem
+	}
+	return m.model.settings.theme().menu.Render(m.list.View() + "\n" + keys)
 }
@@ -0,0 +1,79 @@
+package main
+
+import "github.com/ethanefung/minesweeper/engine"
+
+// jumpMotions are the motions that add to the jumplist, as in vim.
+var jumpMotions = map[string]bool{
+	"gg": true,
+	"G":  true,
+	"H":  true,
+	"M":  true,
+	"L":  true,
+}
+
+func isMarkName(r byte) bool {
+	return r >= 'a' && r <= 'z'
+}
+
+// updateMark handles m{a-z}, '{a-z} and `{a-z}, reporting whether key was
+// one of them. ' goes to the first hidden cell on the mark's row and ` to
+// the mark itself.
+func (g *game) updateMark(key string) bool {
+	if len(key) != 2 || !isMarkName(key[1]) {
+		return false
+	}
+	name := key[1]
+	switch key[0] {
+	case 'm':
+		g.marks[name] = g.cursor
+	case '\'', '`':
+		mark, ok := g.marks[name]
+		if !ok {
+			g.message = "mark not set: " + string(name)
+			return true
+		}
+		if key[0] == '\'' {
+			mark = g.firstHidden(mark.Y)
+		}
+		g.jump(mark)
+	default:
+		return false
+	}
+	return true
+}
+
+// jump moves the cursor to c, remembering where it was in the jumplist.
+// Jumping forgets the jumps ctrl+o went back over.
+func (g *game) jump(c engine.Coord) {
+	g.jumps = append(g.jumps[:g.jumpIndex], g.cursor)
+	g.jumpIndex = len(g.jumps)
+	g.cursor = c
+}
+
+// jumpBack goes to the previous position in the jumplist, like ctrl+o.
+func (g *game) jumpBack(n int) {
+	if g.jumpIndex == len(g.jumps) {
+		// so ctrl+i can come back here
+		g.jumps = append(g.jumps, g.cursor)
+	}
+	g.jumpIndex -= n
+	if g.jumpIndex < 0 {
+		g.jumpIndex = 0
+	}
+	if len(g.jumps) > 0 {
+		g.cursor = g.jumps[g.jumpIndex]
+	}
+}
+
+// jumpForward goes to the next position in the jumplist, like ctrl+i. It
+// does nothing unless ctrl+o went back first.
+func (g *game) jumpForward(n int) {
+	if g.jumpIndex >= len(g.jumps)-1 {
+		return
+	}
+	g.jumpIndex += n
+	if g.jumpIndex > len(g.jumps)-1 {
+		g.jumpIndex = len(g.jumps) - 1
+	}
+	g.cursor = g.jumps[g.jumpIndex]
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/ethanefung/minesweeper/engine"
+)
+
+func TestJumplist(t *testing.T) {
+	tests := []struct {
+		name string
+		keys []string
+		want engine.Coord
+	}{
+		{
+			name: "ctrl+i on an empty jumplist",
+			keys: []string{"tab", "G"},
+			want: engine.Coord{X: 0, Y: 8},
+		},
+		{
+			name: "ctrl+i with nothing newer",
+			keys: []string{"G", "tab"},
+			want: engine.Coord{X: 0, Y: 8},
+		},
+		{
+			name: "ctrl+o back to before a jump",
+			keys: []string{"l", "G", "ctrl+o"},
+			want: engine.Coord{X: 1, Y: 0},
+		},
+		{
+			name: "ctrl+i back to the jump",
+			keys: []string{"l", "G", "ctrl+o", "tab"},
+			want: engine.Coord{X: 1, Y: 8},
+		},
+		{
+			name: "ctrl+o on an empty jumplist",
+			keys: []string{"l", "ctrl+o", "tab", "tab"},
+			want: engine.Coord{X: 1, Y: 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newTestGame(t)
+			typeKeys(g, tt.keys...)
+			if g.cursor != tt.want {
+				t.Errorf("cursor at %v, want %v", g.cursor, tt.want)
+			}
+			if g.jumpIndex < 0 {
+				t.Errorf("jumplist index %d", g.jumpIndex)
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/ethanefung/minesweeper/engine"
+)
+
+// The minimap draws the whole board next to it at a character a cell, the
+// part the viewport shows outlined. ctrl+w moves into it: h, j, k and l move
+// its cursor (H, J, K and L a screen at a time), enter jumps there and esc
+// goes back to the board.
+
+var asciiBorder = lipgloss.Border{
+	Top: "-", Bottom: "-", Left: "|", Right: "|",
+	TopLeft: "+", TopRight: "+", BottomLeft: "+", BottomRight: "+",
+}
+
+// minimapGap is the space between the board and the minimap.
+const minimapGap = 2
+
+// showMinimap reports whether the minimap is drawn, which it always is
+// while the player is moving in it.
+func (g *game) showMinimap() bool {
+	return g.model.settings.minimap || g.inMinimap
+}
+
+// minimapWidth is how many columns the minimap takes next to the board,
+// its border, the viewport's outline and the gap before it included.
+func (g *game) minimapWidth() int {
+	if !g.showMinimap() {
+		return 0
+	}
+	return g.board.Width() + 4 + minimapGap
+}
+
+// openMinimap moves into the minimap, its cursor starting at the board's.
+func (g *game) openMinimap() {
+	g.inMinimap = true
+	g.mapCursor = g.cursor
+}
+
+// updateMinimap handles a key typed in the minimap.
+func (g *game) updateMinimap(key string, n int) {
+	page := engine.Coord{X: g.viewColumns(), Y: g.viewRows()}
+	steps := map[string]engine.Coord{
+		"h": {X: -n},
+		"j": {Y: n},
+		"k": {Y: -n},
+		"l": {X: n},
+		"H": {X: -n * page.X},
+		"J": {Y: n * page.Y},
+		"K": {Y: -n * page.Y},
+		"L": {X: n * page.X},
+	}
+	if d, ok := steps[key]; ok {
+		g.mapCursor.X = clamp(g.mapCursor.X+d.X, 0, g.board.Width()-1)
+		g.mapCursor.Y = clamp(g.mapCursor.Y+d.Y, 0, g.board.Height()-1)
+		return
+	}
+	switch key {
+	case "enter":
+		g.inMinimap = false
+		g.jump(g.mapCursor)
+		g.updateScroll("zz")
+		g.left = g.cursor.X - (g.viewColumns()-1)/2
+		g.follow()
+	case "esc", "ctrl+w":
+		g.inMinimap = false
+	}
+}
+
+// minimapRows are the rows of the minimap that fit next to the board, as
+// the first of them and how many there are. A minimap taller than the board
+// scrolls to follow the cursor it is showing.
+func (g *game) minimapRows() (int, int) {
+	if g.model.height == 0 {
+		return 0, g.board.Height()
+	}
+	// the border and the viewport's outline take two lines each
+	rows := clamp(g.boardLines()-4, 1, g.board.Height())
+	focus := g.cursor.Y
+	if g.inMinimap {
+		focus = g.mapCursor.Y
+	}
+	return clamp(focus-rows/2, 0, g.board.Height()-rows), rows
+}
+
+// minimap draws the minimap, or "" when it is off.
+func (g *game) minimap() string {
+	if !g.showMinimap() {
+		return ""
+	}
+	t := g.model.settings.theme()
+	p := t.palette
+	hidden := lipgloss.NewStyle().Foreground(lipgloss.Color(p.Gutter))
+	revealed := lipgloss.NewStyle().Foreground(lipgloss.Color(p.Hidden))
+	flagged := lipgloss.NewStyle().Foreground(lipgloss.Color(p.Flag))
+
+	lines := lipgloss.NormalBorder()
+	if g.model.settings.ascii {
+		lines = asciiBorder
+	}
+	outline := lipgloss.NewStyle().Foreground(lipgloss.Color(p.CurrentGutter))
+	// the viewport's first and last rows and columns
+	left, right := g.left, g.left+g.viewColumns()-1
+	first, last := g.top, g.top+g.viewRows()-1
+	// edge draws a line of the outline: a corner before the viewport's
+	// first column and after its last and a side along it.
+	edge := func(before, side, after string) string {
+		e := strings.Builder{}
+		for x := 0; x < g.board.Width(); x++ {
+			if x == left {
+				e.WriteString(outline.Render(before))
+			}
+			if x >= left && x <= right {
+				e.WriteString(outline.Render(side))
+			} else {
+				e.WriteString(" ")
+			}
+			if x == right {
+				e.WriteString(outline.Render(after))
+			}
+		}
+		return e.String()
+	}
+	blank := strings.Repeat(" ", g.board.Width()+2)
+
+	top, rows := g.minimapRows()
+	b := strings.Builder{}
+	// a line of the outline off the minimap leaves a blank one instead, so
+	// it is always as tall
+	if first < top {
+		b.WriteString(blank + "\n")
+	}
+	for y := top; y < top+rows; y++ {
+		if y == first {
+			b.WriteString(edge(lines.TopLeft, lines.Top, lines.TopRight) + "\n")
+		}
+		side := " "
+		if y >= first && y <= last {
+			side = outline.Render(lines.Left)
+		}
+		for x := 0; x < g.board.Width(); x++ {
+			if x == left {
+				b.WriteString(side)
+			}
+			c := engine.Coord{X: x, Y: y}
+			_, state := g.board.Cell(x, y)
+			style, content := revealed, "."
+			switch state {
+			case engine.Hidden:
+				style, content = hidden, "#"
+			case engine.Flagged:
+				style, content = flagged, "F"
+			}
+			if g.visible(c) {
+				style = t.selected(style)
+			}
+			if (!g.inMinimap && c == g.cursor) || (g.inMinimap && c == g.mapCursor) {
+				style = t.focused(style)
+			}
+			b.WriteString(style.Render(content))
+			if x == right {
+				b.WriteString(side)
+			}
+		}
+		if y == last {
+			b.WriteString("\n" + edge(lines.BottomLeft, lines.Bottom, lines.BottomRight))
+		}
+		if y < top+rows-1 {
+			b.WriteString("\n")
+		}
+	}
+	if last >= top+rows {
+		b.WriteString("\n" + blank)
+	}
+	border := lipgloss.NewStyle().Border(lines).BorderForeground(lipgloss.Color(p.Gutter))
+	if g.inMinimap {
+		border = border.BorderForeground(lipgloss.Color(p.CurrentGutter))
+	}
+	return border.MarginLeft(minimapGap).Render(b.String())
+}
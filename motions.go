@@ -0,0 +1,142 @@
+package main
+
+import "github.com/ethanefung/minesweeper/engine"
+
+// The motions below treat the board like a buffer of text: every row is a
+// line and, on a row, each run of hidden (or flagged) cells and each run of
+// revealed cells is a word.
+
+func (g *game) index(c engine.Coord) int {
+	return c.Y*g.board.Width() + c.X
+}
+
+func (g *game) coord(i int) engine.Coord {
+	return engine.Coord{X: i % g.board.Width(), Y: i / g.board.Width()}
+}
+
+func (g *game) revealed(c engine.Coord) bool {
+	_, state := g.board.Cell(c.Unwrap())
+	return state == engine.Revealed
+}
+
+// wordStart reports whether a word begins at c.
+func (g *game) wordStart(c engine.Coord) bool {
+	if c.X == 0 {
+		return true
+	}
+	return g.revealed(c) != g.revealed(engine.Coord{X: c.X - 1, Y: c.Y})
+}
+
+// wordEnd reports whether a word ends at c.
+func (g *game) wordEnd(c engine.Coord) bool {
+	if c.X == g.board.Width()-1 {
+		return true
+	}
+	return g.revealed(c) != g.revealed(engine.Coord{X: c.X + 1, Y: c.Y})
+}
+
+// nextWord finds the start of the next word, wrapping onto the next row.
+// It stays on the last cell when there is none.
+func (g *game) nextWord(from engine.Coord) engine.Coord {
+	last := g.board.Width()*g.board.Height() - 1
+	for i := g.index(from) + 1; i <= last; i++ {
+		if g.wordStart(g.coord(i)) {
+			return g.coord(i)
+		}
+	}
+	return g.coord(last)
+}
+
+// previousWord finds the start of this word, or of the one before when the
+// cursor is already on it.
+func (g *game) previousWord(from engine.Coord) engine.Coord {
+	for i := g.index(from) - 1; i >= 0; i-- {
+		if g.wordStart(g.coord(i)) {
+			return g.coord(i)
+		}
+	}
+	return g.coord(0)
+}
+
+// endOfWord finds the end of this word, or of the next one when the cursor
+// is already on it.
+func (g *game) endOfWord(from engine.Coord) engine.Coord {
+	last := g.board.Width()*g.board.Height() - 1
+	for i := g.index(from) + 1; i <= last; i++ {
+		if g.wordEnd(g.coord(i)) {
+			return g.coord(i)
+		}
+	}
+	return g.coord(last)
+}
+
+// firstHidden finds the first cell on row y that is not revealed, much like
+// vim's first non-blank character. A fully revealed row goes to its start.
+func (g *game) firstHidden(y int) engine.Coord {
+	for x := 0; x < g.board.Width(); x++ {
+		if !g.revealed(engine.Coord{X: x, Y: y}) {
+			return engine.Coord{X: x, Y: y}
+		}
+	}
+	return engine.Coord{X: 0, Y: y}
+}
+
+// row returns the cursor's column on row y, keeping y on the board.
+func (g *game) row(y int) engine.Coord {
+	if y < 0 {
+		y = 0
+	}
+	if y > g.board.Height()-1 {
+		y = g.board.Height() - 1
+	}
+	return engine.Coord{X: g.cursor.X, Y: y}
+}
+
+// motion returns where the motion named by key takes the cursor. counted
+// says whether a count was typed, as G and the screen motions use it as a
+// row number rather than a repeat.
+func (g *game) motion(key string, n int, counted bool) (engine.Coord, bool) {
+	c := g.cursor
+	switch key {
+	case "0":
+		return engine.Coord{X: 0, Y: c.Y}, true
+	case "^":
+		return g.firstHidden(c.Y), true
+	case "$":
+		end := g.row(c.Y + n - 1)
+		end.X = g.board.Width() - 1
+		return end, true
+	case "gg":
+		if counted {
+			return g.row(n - 1), true
+		}
+		return g.row(0), true
+	case "G":
+		if counted {
+			return g.row(n - 1), true
+		}
+		return g.row(g.board.Height() - 1), true
+	case "H":
+		return g.row(clamp(g.top+n-1, g.top, g.top+g.viewRows()-1)), true
+	case "M":
+		return g.row(g.top + (g.viewRows()-1)/2), true
+	case "L":
+		return g.row(clamp(g.top+g.viewRows()-n, g.top, g.top+g.viewRows()-1)), true
+	case "w":
+		for i := 0; i < n; i++ {
+			c = g.nextWord(c)
+		}
+		return c, true
+	case "b":
+		for i := 0; i < n; i++ {
+			c = g.previousWord(c)
+		}
+		return c, true
+	case "e":
+		for i := 0; i < n; i++ {
+			c = g.endOfWord(c)
+		}
+		return c, true
+	}
+	return c, false
+}
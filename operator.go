@@ -0,0 +1,298 @@
+package main
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/ethanefung/minesweeper/engine"
+)
+
+// x, d and f are operators, as in vim. They act on the cursor at once and
+// then wait timeoutlen for what else to act on:
+//
+//	operator  = [count] ("x" | "d" | "f") [count] target
+//	target    = motion | object | operator
+//	object    = ("i" | "a") ("w" | "p")
+//
+// A target takes back what was done to the cursor and acts on it instead.
+// A motion acts on the cells from the cursor to where it goes, both
+// included, down the column for the motions between rows. Doubling the
+// operator, or typing no target in time, leaves the cursor alone acted on.
+
+// objectKeys start a text object while an operator is pending.
+var objectKeys = map[string]bool{
+	"i": true,
+	"a": true,
+}
+
+// verticalMotions move between rows, keeping the column.
+var verticalMotions = map[string]bool{
+	"j":  true,
+	"k":  true,
+	"gg": true,
+	"G":  true,
+	"H":  true,
+	"M":  true,
+	"L":  true,
+}
+
+// timeoutKey is recorded into a macro where an operator timed out, so that
+// playing it does not take the next key as a target.
+const timeoutKey = "<timeout>"
+
+type operatorTimeoutMsg struct {
+	id int
+}
+
+// startOperator acts on the cursor with op and waits timeoutlen for a
+// target. A game it ends waits for nothing.
+func (g *game) startOperator(op string, n int) tea.Cmd {
+	moves := g.board.Moves()
+	cmd := g.act(op, n)
+	if g.board.Over() {
+		return cmd
+	}
+	g.operator = op
+	g.operatorCount = n
+	g.operatorTyped = len(g.typed)
+	g.operatorActed = g.board.Moves() > moves
+	g.operatorID++
+	id := g.operatorID
+	wait := time.Duration(g.model.settings.timeoutlen) * time.Millisecond
+	return tea.Batch(cmd, tea.Tick(wait, func(time.Time) tea.Msg {
+		return operatorTimeoutMsg{id}
+	}))
+}
+
+// retract takes back what the pending operator did to the cursor, now that
+// it has a target.
+func (g *game) retract() {
+	if g.operatorActed {
+		g.board.Retract()
+		g.operatorActed = false
+	}
+}
+
+// operate completes the pending operator with key, the target typed after
+// it. A key that is no target leaves the cursor acted on and is then
+// handled as usual.
+func (g *game) operate(key string, n int, counted bool) tea.Cmd {
+	op := g.operator
+	total := g.operatorCount * n
+	g.operator = ""
+	defer func() { g.operatorActed = false }()
+
+	if key == "esc" {
+		return nil
+	}
+	if key == op {
+		if total == g.operatorCount {
+			return nil
+		}
+		g.retract()
+		return g.act(op, total)
+	}
+	if len(key) == 2 && objectKeys[key[:1]] {
+		// the object is found on the board as it was before the operator
+		g.retract()
+		cells, ok := g.object(key)
+		if !ok {
+			return nil
+		}
+		g.changing()
+		return g.apply(cells, g.operation(op))
+	}
+	if _, ok := g.operatorMotion(key, total, counted); ok {
+		g.retract()
+		target, _ := g.operatorMotion(key, total, counted)
+		g.changing()
+		return g.apply(g.span(key, target), g.operation(op))
+	}
+
+	g.splitChange(len(g.typed) - g.operatorTyped)
+	if counted {
+		g.count = strconv.Itoa(n)
+	}
+	return g.press(key)
+}
+
+// flush ends an operator still waiting for its target, leaving the cursor
+// acted on.
+func (g *game) flush() {
+	g.operator = ""
+	g.operatorActed = false
+}
+
+// timeout ends the command being typed when an operator waited too long,
+// keeping it for '.' if it changed the board.
+func (g *game) timeout() {
+	if g.operator == "" {
+		return
+	}
+	g.flush()
+	g.pending, g.count = "", ""
+	if g.changed {
+		g.lastChange = newChange(g.typed)
+	}
+	g.typed = nil
+}
+
+// act is an operator on the cursor alone: x and f go n cells along the
+// last direction and d chords. The cells after the cursor are only revealed
+// or flagged while hidden, so a count never takes a flag back.
+func (g *game) act(op string, n int) tea.Cmd {
+	x, y := g.cursor.Unwrap()
+	g.changing()
+	switch op {
+	case "x":
+		return g.along(n, g.board.Reveal, g.reveal)
+	case "f":
+		return g.along(n, g.board.ToggleFlag, g.flag)
+	}
+	if g.model.settings.aggressiveChord {
+		return g.move(g.board.AggressiveChord(x, y))
+	}
+	err := g.board.Chord(x, y)
+	if errors.Is(err, engine.ErrChordFlags) && g.model.settings.chordPulse {
+		return g.startPulse()
+	}
+	return g.move(err)
+}
+
+// operation is the move an operator makes on each cell of its target.
+func (g *game) operation(op string) func(x, y int) error {
+	switch op {
+	case "x":
+		return g.reveal
+	case "f":
+		return g.flag
+	}
+	return g.chord
+}
+
+// operatorMotion is where a motion typed after an operator goes, h, j, k
+// and l included.
+func (g *game) operatorMotion(key string, n int, counted bool) (engine.Coord, bool) {
+	directions := map[string]engine.Coord{
+		"h": {X: -1},
+		"j": {Y: 1},
+		"k": {Y: -1},
+		"l": {X: 1},
+	}
+	d, ok := directions[key]
+	if !ok {
+		return g.motion(key, n, counted)
+	}
+	c := g.cursor
+	for i := 0; i < n; i++ {
+		next := engine.Coord{X: c.X + d.X, Y: c.Y + d.Y}
+		if !g.board.Inside(next.Unwrap()) {
+			break
+		}
+		c = next
+	}
+	return c, true
+}
+
+// span lists the cells a motion to target covers. w stops short of the
+// word it goes to, as it does in vim.
+func (g *game) span(key string, target engine.Coord) []engine.Coord {
+	cells := []engine.Coord{}
+	if verticalMotions[key] {
+		from, to := g.cursor.Y, target.Y
+		if from > to {
+			from, to = to, from
+		}
+		for y := from; y <= to; y++ {
+			cells = append(cells, engine.Coord{X: g.cursor.X, Y: y})
+		}
+		return cells
+	}
+	from, to := g.index(g.cursor), g.index(target)
+	if key == "w" && to > from && g.wordStart(target) {
+		to--
+	}
+	if from > to {
+		from, to = to, from
+	}
+	for i := from; i <= to; i++ {
+		cells = append(cells, g.coord(i))
+	}
+	return cells
+}
+
+// object lists the cells of a text object: iw is the cursor's word, aw the
+// cursor and its neighbours, ip the region of hidden or revealed cells the
+// cursor is in and ap that region with the cells bordering it.
+func (g *game) object(key string) ([]engine.Coord, bool) {
+	switch key {
+	case "iw":
+		from, to := g.cursor, g.cursor
+		for !g.wordStart(from) {
+			from.X--
+		}
+		for !g.wordEnd(to) {
+			to.X++
+		}
+		cells := []engine.Coord{}
+		for x := from.X; x <= to.X; x++ {
+			cells = append(cells, engine.Coord{X: x, Y: g.cursor.Y})
+		}
+		return cells, true
+	case "aw":
+		return append([]engine.Coord{g.cursor}, g.board.Neighbours(g.cursor.Unwrap())...), true
+	case "ip":
+		return g.region(false), true
+	case "ap":
+		return g.region(true), true
+	}
+	return nil, false
+}
+
+// region finds the cells connected to the cursor, diagonals included, that
+// are revealed when it is, in reading order. around adds the cells
+// bordering them.
+func (g *game) region(around bool) []engine.Coord {
+	inside := map[engine.Coord]bool{g.cursor: true}
+	queue := []engine.Coord{g.cursor}
+	for len(queue) > 0 {
+		c := queue[0]
+		queue = queue[1:]
+		for _, n := range g.board.Neighbours(c.Unwrap()) {
+			if !inside[n] && g.revealed(n) == g.revealed(g.cursor) {
+				inside[n] = true
+				queue = append(queue, n)
+			}
+		}
+	}
+	if around {
+		border := []engine.Coord{}
+		for c := range inside {
+			border = append(border, g.board.Neighbours(c.Unwrap())...)
+		}
+		for _, c := range border {
+			inside[c] = true
+		}
+	}
+	cells := []engine.Coord{}
+	for i := 0; i < g.board.Width()*g.board.Height(); i++ {
+		if inside[g.coord(i)] {
+			cells = append(cells, g.coord(i))
+		}
+	}
+	return cells
+}
+
+// typing shows the command typed so far, as vim's showcmd does.
+func (g *game) typing() string {
+	if g.operator == "" {
+		return g.count + g.pending
+	}
+	count := ""
+	if g.operatorCount > 1 {
+		count = strconv.Itoa(g.operatorCount)
+	}
+	return count + g.operator + g.count + g.pending
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/ethanefung/minesweeper/engine"
+)
+
+// findMine finds a mine with a hidden safe cell left of it.
+func findMine(t *testing.T, g *game) engine.Coord {
+	t.Helper()
+	for y := 0; y < g.board.Height(); y++ {
+		for x := 1; x < g.board.Width(); x++ {
+			val, _ := g.board.Cell(x, y)
+			left, state := g.board.Cell(x-1, y)
+			if val == engine.Mine && left != engine.Mine && state == engine.Hidden {
+				return engine.Coord{X: x, Y: y}
+			}
+		}
+	}
+	t.Fatal("no mine with a hidden cell left of it")
+	return engine.Coord{}
+}
+
+func TestCountedActs(t *testing.T) {
+	tests := []struct {
+		name string
+		// keys are typed with the cursor left of a flagged mine
+		keys []string
+		// want is the state of the cell left of the mine and of the mine
+		// after them
+		want  [2]engine.CellState
+		state engine.GameState
+	}{
+		{
+			name:  "x over a flagged mine",
+			keys:  []string{"2", "x"},
+			want:  [2]engine.CellState{engine.Revealed, engine.Flagged},
+			state: engine.Playing,
+		},
+		{
+			name:  "f over a flagged mine",
+			keys:  []string{"2", "f"},
+			want:  [2]engine.CellState{engine.Flagged, engine.Flagged},
+			state: engine.Playing,
+		},
+		{
+			name:  "f on the flagged cursor takes its flag",
+			keys:  []string{"l", "h", "f", timeoutKey, "l", "2", "f"},
+			want:  [2]engine.CellState{engine.Flagged, engine.Hidden},
+			state: engine.Playing,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newTestGame(t)
+			g.board.Reveal(4, 4)
+			mine := findMine(t, g)
+			g.board.ToggleFlag(mine.Unwrap())
+			g.cursor = engine.Coord{X: mine.X - 1, Y: mine.Y}
+			typeKeys(g, tt.keys...)
+			_, cursor := g.board.Cell(mine.X-1, mine.Y)
+			_, flagged := g.board.Cell(mine.Unwrap())
+			if got := [2]engine.CellState{cursor, flagged}; got != tt.want {
+				t.Errorf("cells are %v, want %v", got, tt.want)
+			}
+			if g.board.State() != tt.state {
+				t.Errorf("game is %v, want %v", g.board.State(), tt.state)
+			}
+		})
+	}
+}
+
+// findHidden finds a cell with hidden cells at each offset from it, which
+// are safe ones when safe is set.
+func findHidden(t *testing.T, g *game, safe bool, offsets ...engine.Coord) engine.Coord {
+	t.Helper()
+	for y := 0; y < g.board.Height(); y++ {
+	cells:
+		for x := 0; x < g.board.Width(); x++ {
+			for _, o := range offsets {
+				c := engine.Coord{X: x + o.X, Y: y + o.Y}
+				if !g.board.Inside(c.Unwrap()) {
+					continue cells
+				}
+				val, state := g.board.Cell(c.Unwrap())
+				if state != engine.Hidden || (safe && val == engine.Mine) {
+					continue cells
+				}
+			}
+			return engine.Coord{X: x, Y: y}
+		}
+	}
+	t.Fatal("no cells to act on")
+	return engine.Coord{}
+}
+
+func TestOperators(t *testing.T) {
+	tests := []struct {
+		name string
+		safe bool
+		// cells are where the keys act, from the cursor, which is flagged
+		// first when flagged is set
+		cells   []engine.Coord
+		flagged bool
+		keys    []string
+		want    engine.CellState
+		// cursor is where the cursor ends, from where it started
+		cursor engine.Coord
+	}{
+		{
+			name:  "x acts at once",
+			safe:  true,
+			cells: []engine.Coord{{}},
+			keys:  []string{"x"},
+			want:  engine.Revealed,
+		},
+		{
+			name:  "x with a motion",
+			safe:  true,
+			cells: []engine.Coord{{}, {Y: 1}},
+			keys:  []string{"x", "j"},
+			want:  engine.Revealed,
+		},
+		{
+			name:  "f with a counted motion",
+			cells: []engine.Coord{{}, {X: 1}, {X: 2}, {X: 3}},
+			keys:  []string{"f", "3", "l"},
+			want:  engine.Flagged,
+		},
+		{
+			name:    "f with a motion over the flagged cursor",
+			cells:   []engine.Coord{{}, {X: 1}},
+			flagged: true,
+			keys:    []string{"f", "l"},
+			want:    engine.Flagged,
+		},
+		{
+			name:   "a motion after the timeout moves",
+			cells:  []engine.Coord{{}, {X: 1}},
+			keys:   []string{"f", timeoutKey, "l"},
+			want:   engine.Flagged,
+			cursor: engine.Coord{X: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newTestGame(t)
+			g.board.Reveal(4, 4)
+			start := findHidden(t, g, tt.safe, tt.cells...)
+			g.cursor = start
+			if tt.flagged {
+				g.board.ToggleFlag(start.Unwrap())
+			}
+			typeKeys(g, tt.keys...)
+			want := engine.Coord{X: start.X + tt.cursor.X, Y: start.Y + tt.cursor.Y}
+			if g.cursor != want {
+				t.Errorf("cursor at %v, want %v", g.cursor, want)
+			}
+			// only the cursor is acted on when the keys moved it
+			cells := tt.cells
+			if tt.cursor != (engine.Coord{}) {
+				cells = cells[:1]
+			}
+			for _, o := range cells {
+				c := engine.Coord{X: start.X + o.X, Y: start.Y + o.Y}
+				if _, state := g.board.Cell(c.Unwrap()); state != tt.want {
+					t.Errorf("%v is %v, want %v", c, state, tt.want)
+				}
+			}
+			// and they undo as one
+			typeKeys(g, timeoutKey, "u")
+			for i, o := range cells {
+				c := engine.Coord{X: start.X + o.X, Y: start.Y + o.Y}
+				want := engine.Hidden
+				if i == 0 && tt.flagged {
+					want = engine.Flagged
+				}
+				if _, state := g.board.Cell(c.Unwrap()); state != want {
+					t.Errorf("%v is %v after undoing, want %v", c, state, want)
+				}
+			}
+		})
+	}
+}
@@ -1,76 +1,185 @@
 package main
 
 import (
+	"errors"
+	"strconv"
 	"strings"
 
-	tea "github.com/charmbracelet/bubbletea"
-	// "github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/ethanefung/minesweeper/engine"
 )
 
-type keymap struct {
-	Up     key.Binding
-	Down   key.Binding
-	Select key.Binding
-	Back   key.Binding
-	Quit   key.Binding
+type playMenu struct {
+	model    *model
+	cursor   int
+	modes    []gameMode
+	rule     engine.FirstReveal
+	noGuess  bool
+	practice bool
+	// seed is handed to every game started from this menu, 0 meaning random.
+	// The entry below the modes edits it through seedInput.
+	seed      int64
+	seedInput textinput.Model
+	form      *customForm
+	keys      keymap
 }
 
-func (k keymap) ShortHelp() []key.Binding {
-	return []key.Binding{k.Up, k.Down, k.Select, k.Back, k.Quit}
+// customForm asks for the size of a custom board. It is open while editing
+// is true and keeps its values between games.
+type customForm struct {
+	editing bool
+	inputs  []textinput.Model
+	focus   int
+	err     error
 }
 
-func (k keymap) FullHelp() [][]key.Binding {
-	return [][]key.Binding{
-		{k.Up, k.Down},
-		{k.Select, k.Back},
-		{k.Quit},
+var customLabels = []string{"width", "height", "mines"}
+
+func newCustomForm() *customForm {
+	size := presets[expert]
+	inputs := make([]textinput.Model, len(customLabels))
+	for i, value := range []int{size.width, size.height, size.mines} {
+		inputs[i] = textinput.New()
+		inputs[i].CharLimit = 4
+		inputs[i].SetValue(strconv.Itoa(value))
 	}
+	return &customForm{inputs: inputs}
 }
 
-var keys = keymap{
-	Up: key.NewBinding(
-		key.WithKeys("k"),
-		key.WithHelp("k", "move up"),
-	),
-	Down: key.NewBinding(
-		key.WithKeys("j"),
-		key.WithHelp("j", "move down"),
-	),
-	Select: key.NewBinding(
-		key.WithKeys("enter"),
-		key.WithHelp("enter", "select option"),
-	),
-	Back: key.NewBinding(
-		key.WithKeys("b"),
-		key.WithHelp("back", "previous menu"),
-	),
-	Quit: key.NewBinding(
-		key.WithKeys("q"),
-		key.WithHelp("q", "quit"),
-	),
+func (f *customForm) open() tea.Cmd {
+	f.editing = true
+	f.err = nil
+	f.focus = 0
+	for i := range f.inputs {
+		f.inputs[i].Blur()
+	}
+	f.inputs[0].CursorEnd()
+	return f.inputs[0].Focus()
 }
 
-type playMenu struct {
-	model  *model
-	cursor int
-	modes  []gameMode
-	keys   keymap
+func (f *customForm) close() {
+	f.editing = false
+	f.inputs[f.focus].Blur()
+}
+
+func (f *customForm) move(step int) tea.Cmd {
+	f.inputs[f.focus].Blur()
+	f.focus = (f.focus + step + len(f.inputs)) % len(f.inputs)
+	f.inputs[f.focus].CursorEnd()
+	return f.inputs[f.focus].Focus()
+}
+
+func (f *customForm) size() (boardSize, error) {
+	values := make([]int, len(f.inputs))
+	for i, input := range f.inputs {
+		v, err := strconv.Atoi(strings.TrimSpace(input.Value()))
+		if err != nil {
+			return boardSize{}, errors.New(customLabels[i] + " must be a number")
+		}
+		values[i] = v
+	}
+	return boardSize{values[0], values[1], values[2]}, nil
+}
+
+func (f *customForm) view(keys keymap) string {
+	b := strings.Builder{}
+	for i, input := range f.inputs {
+		b.WriteString("      " + customLabels[i] + ": ")
+		b.WriteString(strings.Repeat(" ", 6-len(customLabels[i])))
+		b.WriteString(input.View())
+		b.WriteRune('\n')
+	}
+	if f.err != nil {
+		b.WriteString("      " + f.err.Error() + "\n")
+	}
+	b.WriteString("      (" + keys.Next.Help().Key + " to move between fields, " + keys.Select.Help().Key + " to play, " + keys.Cancel.Help().Key + " to go back)\n")
+	return b.String()
 }
 
 func NewPlayMenu(model *model) *playMenu {
-	modes := []gameMode{beginner, intermediate, expert}
-	return &playMenu{model, 0, modes, keys}
+	modes := []gameMode{beginner, intermediate, expert, custom}
+	seedInput := textinput.New()
+	seedInput.Placeholder = "random"
+	seedInput.CharLimit = 18
+	return &playMenu{model, 0, modes, engine.SafeCell, false, false, 0, seedInput, newCustomForm(), model.keys.menu}
+}
+
+func (m *playMenu) updateCustom(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	f := m.form
+	switch {
+	case key.Matches(msg, m.keys.Cancel):
+		f.close()
+		return m.model, nil
+	case key.Matches(msg, m.keys.Next):
+		return m.model, f.move(1)
+	case key.Matches(msg, m.keys.Prev):
+		return m.model, f.move(-1)
+	case key.Matches(msg, m.keys.Select):
+		size, err := f.size()
+		if err == nil {
+			err = engine.Validate(size.config(m.rule, m.noGuess, m.seed))
+		}
+		if err != nil {
+			f.err = err
+			return m.model, nil
+		}
+		f.close()
+		m.model.game.setMode(custom, m.rule, m.noGuess, m.practice, m.seed)
+		m.model.game.setCustom(size)
+		m.model.current = m.model.game
+		return m.model, m.model.game.stopwatch.Start()
+	}
+	var cmd tea.Cmd
+	f.inputs[f.focus], cmd = f.inputs[f.focus].Update(msg)
+	return m.model, cmd
+}
+
+func (m *playMenu) onSeedEntry() bool {
+	return m.cursor == len(m.modes)
+}
+
+func (m *playMenu) updateSeed(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Cancel):
+		m.seedInput.Blur()
+		return m.model, nil
+	case key.Matches(msg, m.keys.Select):
+		value := strings.TrimSpace(m.seedInput.Value())
+		if value == "" {
+			m.seed = 0
+			m.seedInput.Blur()
+			return m.model, nil
+		}
+		seed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || seed < 0 {
+			m.seedInput.SetValue("")
+			return m.model, nil
+		}
+		m.seed = seed
+		m.seedInput.Blur()
+		return m.model, nil
+	}
+	var cmd tea.Cmd
+	m.seedInput, cmd = m.seedInput.Update(msg)
+	return m.model, cmd
 }
 
 func (m *playMenu) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if m.seedInput.Focused() {
+			return m.updateSeed(msg)
+		}
+		if m.form.editing {
+			return m.updateCustom(msg)
+		}
 		switch {
 		case key.Matches(msg, m.keys.Quit):
 			return m.model, tea.Quit
 		case key.Matches(msg, m.keys.Down):
-			if m.cursor >= len(m.modes)-1 {
+			if m.cursor >= len(m.modes) {
 				break
 			}
 			m.cursor += 1
@@ -79,13 +188,40 @@ func (m *playMenu) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				break
 			}
 			m.cursor -= 1
+		case key.Matches(msg, m.keys.Rule):
+			m.rule = engine.FirstReveals[(int(m.rule)+1)%len(engine.FirstReveals)]
+		case key.Matches(msg, m.keys.NoGuess):
+			m.noGuess = !m.noGuess
+		case key.Matches(msg, m.keys.Practice):
+			m.practice = !m.practice
 		case key.Matches(msg, m.keys.Back):
 			m.model.current = m.model.mainMenu
 		case key.Matches(msg, m.keys.Select):
-			m.model.game.setMode(m.modes[m.cursor])
+			if m.onSeedEntry() {
+				m.seedInput.SetValue("")
+				if m.seed != 0 {
+					m.seedInput.SetValue(strconv.FormatInt(m.seed, 10))
+				}
+				m.seedInput.CursorEnd()
+				return m.model, m.seedInput.Focus()
+			}
+			if m.modes[m.cursor] == custom {
+				return m.model, m.form.open()
+			}
+			m.model.game.setMode(m.modes[m.cursor], m.rule, m.noGuess, m.practice, m.seed)
 			m.model.current = m.model.game
 			return m.model, m.model.game.stopwatch.Start()
 		}
+	default:
+		// keeps the cursors of the inputs blinking
+		var cmd tea.Cmd
+		if m.form.editing {
+			f := m.form
+			f.inputs[f.focus], cmd = f.inputs[f.focus].Update(msg)
+			return m.model, cmd
+		}
+		m.seedInput, cmd = m.seedInput.Update(msg)
+		return m.model, cmd
 	}
 	return m.model, nil
 }
@@ -100,6 +236,35 @@ func (m *playMenu) view() string {
 		}
 		b.WriteString(mode.String())
 		b.WriteRune('\n')
+		if mode == custom && m.form.editing {
+			b.WriteString(m.form.view(m.keys))
+		}
+	}
+	if m.onSeedEntry() {
+		b.WriteString("[>] ")
+	} else {
+		b.WriteString("[ ] ")
+	}
+	b.WriteString("play seed: ")
+	switch {
+	case m.seedInput.Focused():
+		b.WriteString(m.seedInput.View())
+	case m.seed == 0:
+		b.WriteString("random")
+	default:
+		b.WriteString(strconv.FormatInt(m.seed, 10))
+	}
+	b.WriteRune('\n')
+	b.WriteString("\nfirst reveal: " + m.rule.String() + " (press '" + m.keys.Rule.Help().Key + "' to change)\n")
+	if m.noGuess {
+		b.WriteString("no guess boards: on, they always start with a safe opening (press '" + m.keys.NoGuess.Help().Key + "' to change)\n")
+	} else {
+		b.WriteString("no guess boards: off (press '" + m.keys.NoGuess.Help().Key + "' to change)\n")
+	}
+	if m.practice {
+		b.WriteString("practice mode: on, 'u' can take back a lost game (press '" + m.keys.Practice.Help().Key + "' to change)\n")
+	} else {
+		b.WriteString("practice mode: off (press '" + m.keys.Practice.Help().Key + "' to change)\n")
 	}
 	return b.String()
 }
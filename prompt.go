@@ -0,0 +1,140 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// prompt is a line typed at the bottom of the board, like vim's command
+// line. It remembers what was entered and can complete the word under the
+// cursor.
+type prompt struct {
+	input   textinput.Model
+	history []string
+	// browsing is where up and down are in history, len(history) being the
+	// line being typed
+	browsing int
+	// matches are the completions tab cycles through, next the one it
+	// shows next
+	matches []string
+	next    int
+}
+
+func newPrompt(symbol string) *prompt {
+	input := textinput.New()
+	input.Prompt = symbol
+	return &prompt{input: input}
+}
+
+func (p *prompt) active() bool {
+	return p.input.Focused()
+}
+
+func (p *prompt) open() tea.Cmd {
+	p.input.SetValue("")
+	p.browsing = len(p.history)
+	p.matches = nil
+	return p.input.Focus()
+}
+
+func (p *prompt) close() {
+	p.input.Blur()
+	p.matches = nil
+}
+
+// submit closes the prompt and returns the line typed, adding it to the
+// history.
+func (p *prompt) submit() string {
+	line := strings.TrimSpace(p.input.Value())
+	p.close()
+	if line != "" && (len(p.history) == 0 || p.history[len(p.history)-1] != line) {
+		p.history = append(p.history, line)
+	}
+	return line
+}
+
+// browse moves through the history, step -1 going back in time.
+func (p *prompt) browse(step int) {
+	i := p.browsing + step
+	if i < 0 || i > len(p.history) {
+		return
+	}
+	p.browsing = i
+	if i == len(p.history) {
+		p.input.SetValue("")
+	} else {
+		p.input.SetValue(p.history[i])
+	}
+	p.input.CursorEnd()
+}
+
+// complete replaces the last word of the line with the next of candidates
+// that starts with it. candidates is only asked for on the first tab.
+func (p *prompt) complete(candidates func(words []string) []string) {
+	value := p.input.Value()
+	start := strings.LastIndex(value, " ") + 1
+	if p.matches == nil {
+		words := strings.Fields(value)
+		if start == len(value) {
+			// completing a word not started yet
+			words = append(words, "")
+		}
+		word := value[start:]
+		for _, c := range candidates(words) {
+			if strings.HasPrefix(c, word) {
+				p.matches = append(p.matches, c)
+			}
+		}
+		p.next = 0
+	}
+	if len(p.matches) == 0 {
+		return
+	}
+	p.input.SetValue(value[:start] + p.matches[p.next])
+	p.input.CursorEnd()
+	p.next = (p.next + 1) % len(p.matches)
+}
+
+// handle deals with a key typed while the prompt is open. enter hands the
+// line to submit and tab completes it from candidates.
+func (p *prompt) handle(msg tea.KeyMsg, submit func(string) tea.Cmd, candidates func([]string) []string) tea.Cmd {
+	switch msg.String() {
+	case "ctrl+c":
+		return tea.Quit
+	case "esc":
+		p.close()
+	case "enter":
+		return submit(p.submit())
+	case "tab":
+		p.complete(candidates)
+	case "up":
+		p.browse(-1)
+	case "down":
+		p.browse(1)
+	case "backspace":
+		if p.input.Value() == "" {
+			p.close()
+			return nil
+		}
+		return p.update(msg)
+	default:
+		return p.update(msg)
+	}
+	return nil
+}
+
+// update passes a message to the input. Any key but tab ends a completion.
+func (p *prompt) update(msg tea.Msg) tea.Cmd {
+	if _, ok := msg.(tea.KeyMsg); ok {
+		p.matches = nil
+	}
+	var cmd tea.Cmd
+	p.input, cmd = p.input.Update(msg)
+	return cmd
+}
+
+func (p *prompt) view() string {
+	return p.input.View()
+}
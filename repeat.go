@@ -0,0 +1,139 @@
+package main
+
+import (
+	"strconv"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// change is the last command that changed the board, kept for '.'. count
+// is the count it was typed with and keys the rest of it.
+type change struct {
+	count string
+	keys  []string
+}
+
+func newChange(typed []string) *change {
+	i := 0
+	for i < len(typed) && len(typed[i]) == 1 && typed[i][0] >= '0' && typed[i][0] <= '9' {
+		i++
+	}
+	c := &change{keys: append([]string(nil), typed[i:]...)}
+	for _, digit := range typed[:i] {
+		c.count += digit
+	}
+	return c
+}
+
+// countKeys spells out a count and a key as they would be typed, "12l" being
+// "1", "2", "l". A count of 0 needs no keys at all.
+func countKeys(n int, key string) []string {
+	if n <= 0 {
+		return nil
+	}
+	keys := []string{}
+	if n > 1 {
+		for _, digit := range strconv.Itoa(n) {
+			keys = append(keys, string(digit))
+		}
+	}
+	return append(keys, key)
+}
+
+// input types a key on the board, keeping the keys of the command in
+// progress so that a change can be repeated with '.'.
+func (g *game) input(key string) tea.Cmd {
+	if key == "." && g.pending == "" && g.visual == noVisual {
+		return g.repeat()
+	}
+	if key == "q" && g.pending == "" && g.recording != "" {
+		g.count = ""
+		g.stopRecording()
+		return nil
+	}
+	g.typed = append(g.typed, key)
+	g.changed = false
+	cmd := g.press(key)
+	if g.pending == "" && g.count == "" && g.operator == "" {
+		if g.changed {
+			g.lastChange = newChange(g.typed)
+		}
+		g.typed = nil
+	}
+	return cmd
+}
+
+// changing marks the command being typed as a change for '.' to repeat.
+func (g *game) changing() {
+	g.changed = true
+}
+
+// repeat types the last change again at the cursor. A count replaces the
+// one the change was typed with.
+func (g *game) repeat() tea.Cmd {
+	if g.lastChange == nil {
+		g.count = ""
+		g.typed = nil
+		return nil
+	}
+	if g.count != "" {
+		g.lastChange.count = g.count
+		g.count = ""
+	}
+	g.typed = nil
+	keys := []string{}
+	for _, digit := range g.lastChange.count {
+		keys = append(keys, string(digit))
+	}
+	keys = append(keys, g.lastChange.keys...)
+
+	cmds := []tea.Cmd{}
+	for _, key := range keys {
+		cmds = append(cmds, g.press(key))
+	}
+	// an operator repeated alone takes no target
+	g.flush()
+	return tea.Batch(cmds...)
+}
+
+// visualChange spells out the selection about to be acted on by op as keys
+// starting from its first cell, so '.' can select the same shape again.
+func (g *game) visualChange(op string) []string {
+	from, to := g.anchor, g.cursor
+	if g.index(from) > g.index(to) {
+		from, to = to, from
+	}
+	if g.visual == blockVisual {
+		width, height := to.X-from.X, to.Y-from.Y
+		if width < 0 {
+			width = -width
+		}
+		keys := []string{"ctrl+v"}
+		keys = append(keys, countKeys(height, "j")...)
+		keys = append(keys, countKeys(width, "l")...)
+		return append(keys, op)
+	}
+	keys := []string{"v"}
+	if to.Y == from.Y {
+		keys = append(keys, countKeys(to.X-from.X, "l")...)
+		return append(keys, op)
+	}
+	keys = append(keys, countKeys(to.Y-from.Y, "j")...)
+	keys = append(keys, "0")
+	keys = append(keys, countKeys(to.X, "l")...)
+	return append(keys, op)
+}
+
+// splitChange ends the command being typed before its last n keys, which
+// begin the next one. The keys before them are kept for '.' if they changed
+// the board.
+func (g *game) splitChange(n int) {
+	if n <= 0 || len(g.typed) < n {
+		return
+	}
+	if g.changed {
+		g.lastChange = newChange(g.typed[:len(g.typed)-n])
+	}
+	g.typed = g.typed[len(g.typed)-n:]
+	g.changed = false
+}
@@ -4,9 +4,11 @@ import (
 	"encoding/csv"
 	"log"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
@@ -14,32 +16,37 @@ type saveMenu struct {
 	model    *model
 	initials []rune
 	cursor   int
+	keys     saveKeymap
 }
 
-var baseFocusedStyle = createFocusedStyle(baseStyle)
-
 func NewSaveMenu(m *model) *saveMenu {
 	return &saveMenu{
 		model:    m,
 		initials: []rune{'A', 'A', 'A'},
 		cursor:   0,
+		keys:     m.keys.save,
 	}
 }
 
 func (m *saveMenu) view() string {
 	b := strings.Builder{}
 	b.WriteString("\n")
-	b.WriteString("Change the initials using h, j, k, and l. Press y to save.\n")
-	b.WriteString("Pressing n will take you to the menu\n\n")
+	k := m.keys
+	b.WriteString("Change the initials using " + k.Left.Help().Key + ", " + k.Next.Help().Key + ", " + k.Previous.Help().Key + ", and " + k.Right.Help().Key + ". Press " + k.Yes.Help().Key + " to save.\n")
+	b.WriteString("Pressing " + k.No.Help().Key + " will take you to the menu\n\n")
+	if m.model.game.usedUndo {
+		b.WriteString("Undo was used, so this game is saved unranked.\n\n")
+	}
+	t := m.model.settings.theme()
 	for i, char := range m.initials {
 		str := string(char)
 		if i == m.cursor {
-			b.WriteString(baseFocusedStyle.Render(str))
+			b.WriteString(t.focused(t.cell).Render(str))
 			continue
 		}
-		b.WriteString(baseStyle.Render(str))
+		b.WriteString(t.cell.Render(str))
 	}
-	b.WriteString("\n\nSave? (y / n)\n")
+	b.WriteString("\n\nSave? (" + k.Yes.Help().Key + " / " + k.No.Help().Key + ")\n")
 
 	return b.String()
 }
@@ -47,30 +54,30 @@ func (m *saveMenu) view() string {
 func (m *saveMenu) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c", "q":
+		switch {
+		case key.Matches(msg, m.keys.Quit):
 			return m.model, tea.Quit
-		case "n":
+		case key.Matches(msg, m.keys.No):
 			m.model.game = NewGame(m.model)
 			m.model.current = m.model.mainMenu
-		case "y":
+		case key.Matches(msg, m.keys.Yes):
 			save(m.model.game, m.initials)
 			m.model.game = NewGame(m.model)
 			m.model.scores.reevaluate()
 			m.model.current = m.model.scores
-		case "h":
+		case key.Matches(msg, m.keys.Left):
 			if m.cursor > 0 {
 				m.cursor--
 			}
-		case "l":
+		case key.Matches(msg, m.keys.Right):
 			if m.cursor < 2 {
 				m.cursor++
 			}
-		case "j":
+		case key.Matches(msg, m.keys.Next):
 			if m.initials[m.cursor] < 'Z' {
 				m.initials[m.cursor]++
 			}
-		case "k":
+		case key.Matches(msg, m.keys.Previous):
 			if m.initials[m.cursor] > 'A' {
 				m.initials[m.cursor]--
 			}
@@ -92,7 +99,20 @@ func save(game *game, initials []rune) {
 	if err != nil {
 		log.Fatal(err)
 	}
-	err = writer.Write([]string{string(initials), game.stopwatch.Elapsed().String(), string(currentTime), game.mode.String()})
+	record := make([]string, numFields)
+	record[initialsField] = string(initials)
+	record[durationField] = game.stopwatch.Elapsed().String()
+	record[playedField] = string(currentTime)
+	record[modeField] = game.mode.String()
+	record[ruleField] = game.rule.String()
+	record[seedField] = strconv.FormatInt(game.board.Config().Seed, 10)
+	record[boardField] = game.size().String()
+	record[noGuessField] = strconv.FormatBool(game.noGuess)
+	record[unrankedField] = strconv.FormatBool(game.usedUndo)
+	if first, ok := game.board.First(); ok {
+		record[openedField] = cellName(first)
+	}
+	err = writer.Write(record)
 
 	if err != nil {
 		log.Fatal(err)
@@ -9,8 +9,10 @@ import (
 	"strings"
 	"time"
 
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/table"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/ethanefung/minesweeper/engine"
 )
 
 /*
@@ -19,20 +21,81 @@ scores ...
 type scores struct {
 	model *model
 	table table.Model
+	keys  keymap
+}
+
+// the columns of a row in scores.csv. Rows written by older versions stop
+// short, so read them through field.
+const (
+	initialsField = iota
+	durationField
+	playedField
+	modeField
+	ruleField
+	seedField
+	boardField
+	noGuessField
+	unrankedField
+	openedField
+	numFields
+)
+
+// fieldDefaults are used for columns missing from older rows. Games saved
+// before the first reveal rule existed were always classic.
+var fieldDefaults = map[int]string{
+	ruleField:     engine.Classic.String(),
+	noGuessField:  "false",
+	unrankedField: "false",
+}
+
+func field(record []string, i int) string {
+	if i < len(record) {
+		return record[i]
+	}
+	if i == boardField {
+		// only the preset modes could be saved before the board was recorded
+		mode, err := parseGameMode(field(record, modeField))
+		if size, ok := presets[mode]; err == nil && ok {
+			return size.String()
+		}
+	}
+	return fieldDefaults[i]
+}
+
+// configuration identifies the games a score competes with: only games of
+// the same mode, board, first reveal rule and kind of layout are ranked
+// against each other.
+func configuration(record []string) string {
+	return field(record, modeField) + " " + field(record, boardField) + " " + field(record, ruleField) + " " + field(record, noGuessField)
 }
 
 type sortable [][]string
 
 func (records sortable) Len() int      { return len(records) }
 func (records sortable) Swap(i, j int) { records[i], records[j] = records[j], records[i] }
+
+// Less groups records by configuration, in the order of the play menu, puts
+// unranked records after ranked ones and then orders them by time.
 func (records sortable) Less(i, j int) bool {
 	a, b := records[i], records[j]
 
-	durationA, err := time.ParseDuration(a[1])
+	if configuration(a) != configuration(b) {
+		modeA, _ := parseGameMode(field(a, modeField))
+		modeB, _ := parseGameMode(field(b, modeField))
+		if modeA != modeB {
+			return modeA < modeB
+		}
+		return configuration(a) < configuration(b)
+	}
+	if field(a, unrankedField) != field(b, unrankedField) {
+		return field(a, unrankedField) == "false"
+	}
+
+	durationA, err := time.ParseDuration(a[durationField])
 	if err != nil {
 		log.Fatal(err)
 	}
-	durationB, err := time.ParseDuration(b[1])
+	durationB, err := time.ParseDuration(b[durationField])
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -40,11 +103,11 @@ func (records sortable) Less(i, j int) bool {
 		return durationA < durationB
 	}
 
-	playedA, err := time.Parse(time.RFC3339, a[2])
+	playedA, err := time.Parse(time.RFC3339, a[playedField])
 	if err != nil {
 		log.Fatal(err)
 	}
-	playedB, err := time.Parse(time.RFC3339, b[2])
+	playedB, err := time.Parse(time.RFC3339, b[playedField])
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -57,20 +120,20 @@ func NewScores(m *model) *scores {
 	if err != nil {
 		log.Fatal(err)
 	}
-	return &scores{m, NewTable(records)}
+	return &scores{m, NewTable(records), m.keys.menu}
 }
 
 func (s *scores) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c", "q":
+		switch {
+		case key.Matches(msg, s.keys.Quit):
 			return s.model, tea.Quit
-		case "b":
+		case key.Matches(msg, s.keys.Back):
 			s.model.current = s.model.mainMenu
-		case "j":
+		case key.Matches(msg, s.keys.Down):
 			s.table.MoveDown(1)
-		case "k":
+		case key.Matches(msg, s.keys.Up):
 			s.table.MoveUp(1)
 		}
 	}
@@ -80,12 +143,12 @@ func (s *scores) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 func (s *scores) view() string {
 	b := strings.Builder{}
 	b.WriteString(s.table.View() + "\n")
-	b.WriteString("Press 'b' to exit to the main menu.")
+	b.WriteString("Press '" + s.keys.Back.Help().Key + "' to exit to the main menu.")
 	return b.String()
 }
 
 /*
-	reevaluate will read the csv file again and set the records to the new values
+reevaluate will read the csv file again and set the records to the new values
 */
 func (s *scores) reevaluate() {
 	records, err := readCSV()
@@ -99,8 +162,13 @@ func NewTable(records sortable) table.Model {
 	columns := []table.Column{
 		{Title: "Rank", Width: 5},
 		{Title: "Mode", Width: 12},
+		{Title: "Board", Width: 10},
+		{Title: "Rule", Width: 12},
+		{Title: "No guess", Width: 8},
 		{Title: "Time", Width: 10},
 		{Title: "Player", Width: 6},
+		{Title: "Seed", Width: 10},
+		{Title: "Opened", Width: 6},
 	}
 
 	sort.Sort(records)
@@ -128,28 +196,53 @@ func readCSV() (sortable, error) {
 	defer file.Close()
 
 	reader := csv.NewReader(file)
+	// older rows have fewer columns than newer ones
+	reader.FieldsPerRecord = -1
 	return reader.ReadAll()
 }
 
 func deriveRows(records [][]string) []table.Row {
 	rows := []table.Row{}
-	for i, record := range records {
-		rows = append(rows, table.Row{strconv.Itoa(i + 1), record[3], record[1], record[0]})
+	ranks := map[string]int{}
+	for _, record := range records {
+		rank := "-"
+		if field(record, unrankedField) == "false" {
+			ranks[configuration(record)]++
+			rank = strconv.Itoa(ranks[configuration(record)])
+		}
+		rows = append(rows, table.Row{
+			rank,
+			field(record, modeField),
+			field(record, boardField),
+			field(record, ruleField),
+			noGuessColumn(field(record, noGuessField)),
+			field(record, durationField),
+			field(record, initialsField),
+			field(record, seedField),
+			field(record, openedField),
+		})
 	}
 	return rows
 }
 
+func noGuessColumn(value string) string {
+	if value == "true" {
+		return "yes"
+	}
+	return ""
+}
+
 func latestIndex(records [][]string) int {
 	if len(records) == 0 {
 		return 0
 	}
 	var l int
 	for i, record := range records {
-		t, err := time.Parse(time.RFC3339, record[2])
+		t, err := time.Parse(time.RFC3339, record[playedField])
 		if err != nil {
 			log.Fatal(err)
 		}
-		latest, err := time.Parse(time.RFC3339, records[l][2])
+		latest, err := time.Parse(time.RFC3339, records[l][playedField])
 		if err != nil {
 			log.Fatal(err)
 		}
@@ -157,8 +250,5 @@ func latestIndex(records [][]string) int {
 			l = i
 		}
 	}
-	if l == 0 {
-		log.Fatal(records[0][2])
-	}
 	return l
 }
@@ -0,0 +1,51 @@
+package main
+
+import "testing"
+
+func TestField(t *testing.T) {
+	tests := []struct {
+		name   string
+		record []string
+		field  int
+		want   string
+	}{
+		{"a column the row has", []string{"abc", "1m2s", "2022-01-02", "expert"}, modeField, "expert"},
+		{"rule of a row before rules", []string{"abc", "1m2s", "2022-01-02", "expert"}, ruleField, "classic"},
+		{"board of a preset", []string{"abc", "1m2s", "2022-01-02", "intermediate"}, boardField, "16x16/40"},
+		{"board of an unknown mode", []string{"abc", "1m2s", "2022-01-02", "giant"}, boardField, ""},
+		{"board the row has", []string{"abc", "1m2s", "2022-01-02", "custom", "safe cell", "1", "20x10/30"}, boardField, "20x10/30"},
+		{"no guess", []string{"abc", "1m2s", "2022-01-02", "beginner"}, noGuessField, "false"},
+		{"unranked", []string{"abc", "1m2s", "2022-01-02", "beginner"}, unrankedField, "false"},
+		{"seed", []string{"abc", "1m2s", "2022-01-02", "beginner"}, seedField, ""},
+		{"opened", []string{"abc", "1m2s", "2022-01-02", "beginner", "safe cell", "1", "9x9/10", "false", "false"}, openedField, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := field(tt.record, tt.field); got != tt.want {
+				t.Errorf("field %d is %q, want %q", tt.field, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfiguration(t *testing.T) {
+	old := []string{"abc", "1m2s", "2022-01-02", "beginner"}
+	tests := []struct {
+		name   string
+		record []string
+		same   bool
+	}{
+		{"a classic beginner game", []string{"def", "2m", "2023-01-02", "beginner", "classic", "7", "9x9/10", "false", "false", "A1"}, true},
+		{"an unranked one", []string{"def", "2m", "2023-01-02", "beginner", "classic", "7", "9x9/10", "false", "true", "A1"}, true},
+		{"a safe cell one", []string{"def", "2m", "2023-01-02", "beginner", "safe cell", "7", "9x9/10", "false", "false", "A1"}, false},
+		{"a no guess one", []string{"def", "2m", "2023-01-02", "beginner", "classic", "7", "9x9/10", "true", "false", "A1"}, false},
+		{"a custom one the same size", []string{"def", "2m", "2023-01-02", "custom", "classic", "7", "9x9/10", "false", "false", "A1"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := configuration(old) == configuration(tt.record); got != tt.same {
+				t.Errorf("%q and %q ranked together is %v, want %v", configuration(old), configuration(tt.record), got, tt.same)
+			}
+		})
+	}
+}
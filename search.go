@@ -0,0 +1,97 @@
+package main
+
+import (
+	"strconv"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/ethanefung/minesweeper/engine"
+)
+
+// A search pattern picks out cells by what they show:
+//
+//	0 to 8  revealed cells with that number
+//	?       the frontier, hidden cells next to a revealed number
+//	f       flagged cells
+//	h       hidden cells
+func searchCompletions(words []string) []string {
+	return []string{"0", "1", "2", "3", "4", "5", "6", "7", "8", "?", "f", "h"}
+}
+
+func validPattern(pattern string) bool {
+	for _, p := range searchCompletions(nil) {
+		if p == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// matches reports whether the cell at c fits pattern.
+func (g *game) matches(pattern string, c engine.Coord) bool {
+	val, state := g.board.Cell(c.Unwrap())
+	switch pattern {
+	case "f":
+		return state == engine.Flagged
+	case "h":
+		return state == engine.Hidden
+	case "?":
+		if state != engine.Hidden {
+			return false
+		}
+		for _, n := range g.board.Neighbours(c.Unwrap()) {
+			if v, s := g.board.Cell(n.Unwrap()); s == engine.Revealed && v > 0 {
+				return true
+			}
+		}
+		return false
+	}
+	number, err := strconv.Atoi(pattern)
+	return err == nil && state == engine.Revealed && val == number
+}
+
+// highlighted reports whether c matches the last search and should stand
+// out.
+func (g *game) highlighted(c engine.Coord) bool {
+	return g.pattern != "" && g.model.settings.hlsearch && g.matches(g.pattern, c)
+}
+
+// search runs a pattern typed after '/', jumping to the first match after
+// the cursor.
+func (g *game) search(pattern string) tea.Cmd {
+	if pattern == "" {
+		// an empty search repeats the last one, as in vim
+		pattern = g.pattern
+	}
+	if !validPattern(pattern) {
+		g.message = "not a pattern: " + pattern + " (try 0-8, ?, f or h)"
+		return nil
+	}
+	g.pattern = pattern
+	g.searchNext(1, 1)
+	return nil
+}
+
+// searchNext jumps to the nth match of the last search, going forward in
+// reading order when step is 1 and back when it is -1, wrapping around the
+// board.
+func (g *game) searchNext(n, step int) {
+	if g.pattern == "" {
+		g.message = "no previous search"
+		return
+	}
+	cells := g.board.Width() * g.board.Height()
+	i := g.index(g.cursor)
+	for hits := 0; hits < n; hits++ {
+		found := false
+		for tries := 0; tries < cells && !found; tries++ {
+			i = (i + step + cells) % cells
+			found = g.matches(g.pattern, g.coord(i))
+		}
+		if !found {
+			g.message = "pattern not found: " + g.pattern
+			return
+		}
+	}
+	g.message = "/" + g.pattern
+	g.jump(g.coord(i))
+}
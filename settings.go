@@ -0,0 +1,341 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// settings change how the game plays without changing what is ranked.
+type settings struct {
+	// aggressiveChord makes 'd' reveal every unflagged neighbour, however
+	// many flags surround the number
+	aggressiveChord bool
+	// chordPulse highlights the neighbours of a number when 'd' refuses to
+	// chord it
+	chordPulse bool
+	// hlsearch highlights the cells matching the last search
+	hlsearch bool
+	// operatorPending lets a motion typed after x, d or f widen what they
+	// acted on, as vim's operators take one. timeoutlen is how long in
+	// milliseconds they wait for it.
+	operatorPending bool
+	timeoutlen      int
+	// number and relativenumber show row numbers left of the board, as in
+	// vim, and columnLetters names the columns above it
+	number         bool
+	relativeNumber bool
+	columnLetters  bool
+	// minimap draws the whole board small next to it
+	minimap bool
+	// themeName is the theme the game is drawn with, auto picking the
+	// built-in one that suits the terminal
+	themeName string
+	// ascii draws the board without emoji
+	ascii bool
+	// densityName is how tightly the board is drawn
+	densityName string
+}
+
+// asciiEnv and themeEnv set the ascii and theme settings from the
+// environment, for terminals that are always used the same way.
+const (
+	asciiEnv = "MINESWEEPER_ASCII"
+	themeEnv = "MINESWEEPER_THEME"
+)
+
+// NewSettings starts from the default settings and those set in the
+// environment. A theme the environment names that does not exist is left
+// out and the error says why.
+func NewSettings() (*settings, error) {
+	s := &settings{
+		aggressiveChord: false,
+		chordPulse:      true,
+		hlsearch:        true,
+		operatorPending: true,
+		timeoutlen:      500,
+		themeName:       "auto",
+		densityName:     "classic",
+		ascii:           os.Getenv(asciiEnv) != "",
+	}
+	if name := os.Getenv(themeEnv); name != "" {
+		if _, err := s.set("theme=" + name); err != nil {
+			return s, fmt.Errorf("%s: %w", themeEnv, err)
+		}
+	}
+	return s, nil
+}
+
+// option is a setting the settings menu and :set can change. name and
+// short are what :set knows it by. A toggle is either on or off, any other
+// option is read and written as text and the menu cycles through its
+// values.
+type option struct {
+	name   string
+	short  string
+	label  string
+	toggle func(*settings) *bool
+	values func() []string
+	get    func(*settings) string
+	set    func(*settings, string) error
+}
+
+var options = []option{
+	{
+		name: "aggressivechord", short: "ac", label: "aggressive chord ('d' ignores flags)",
+		toggle: func(s *settings) *bool { return &s.aggressiveChord },
+	},
+	{
+		name: "chordpulse", short: "cp", label: "pulse neighbours when 'd' does nothing",
+		toggle: func(s *settings) *bool { return &s.chordPulse },
+	},
+	{
+		name: "hlsearch", short: "hls", label: "highlight the cells found by '/'",
+		toggle: func(s *settings) *bool { return &s.hlsearch },
+	},
+	{
+		name: "operatorpending", short: "op", label: "x, d and f take a motion",
+		toggle: func(s *settings) *bool { return &s.operatorPending },
+	},
+	{
+		name: "timeoutlen", short: "tm", label: "milliseconds x, d and f take a motion for",
+		values: func() []string { return []string{"250", "500", "1000"} },
+		get:    func(s *settings) string { return strconv.Itoa(s.timeoutlen) },
+		set: func(s *settings, value string) error {
+			ms, err := strconv.Atoi(value)
+			if err != nil || ms <= 0 {
+				return errors.New("timeoutlen must be a number of milliseconds")
+			}
+			s.timeoutlen = ms
+			return nil
+		},
+	},
+	{
+		name: "theme", short: "th", label: "theme",
+		values: themeNames,
+		get:    func(s *settings) string { return s.themeName },
+		set: func(s *settings, value string) error {
+			if _, ok := themes[value]; !ok && value != "auto" {
+				return errors.New("no theme is called " + value)
+			}
+			s.themeName = value
+			return nil
+		},
+	},
+	{
+		name: "density", short: "de", label: "how tightly the board is drawn",
+		values: densityNames,
+		get:    func(s *settings) string { return s.densityName },
+		set: func(s *settings, value string) error {
+			if _, ok := findDensity(value); !ok {
+				return errors.New("no density is called " + value)
+			}
+			s.densityName = value
+			return nil
+		},
+	},
+	{
+		name: "ascii", short: "asc", label: "draw without emoji",
+		toggle: func(s *settings) *bool { return &s.ascii },
+	},
+	{
+		name: "minimap", short: "mm", label: "draw the whole board small next to it",
+		toggle: func(s *settings) *bool { return &s.minimap },
+	},
+	{
+		name: "number", short: "nu", label: "number the rows",
+		toggle: func(s *settings) *bool { return &s.number },
+	},
+	{
+		name: "relativenumber", short: "rnu", label: "number the rows from the cursor",
+		toggle: func(s *settings) *bool { return &s.relativeNumber },
+	},
+	{
+		name: "columnletters", short: "cl", label: "letter the columns",
+		toggle: func(s *settings) *bool { return &s.columnLetters },
+	},
+}
+
+// theme is the theme the game is drawn with.
+func (s *settings) theme() *theme {
+	if s.themeName == "auto" {
+		return themes[autoTheme]
+	}
+	return themes[s.themeName]
+}
+
+func (s *settings) density() density {
+	d, _ := findDensity(s.densityName)
+	return d
+}
+
+func (s *settings) glyphs() glyphs {
+	if s.ascii {
+		return asciiGlyphs
+	}
+	return emojiGlyphs
+}
+
+func findOption(name string) (option, bool) {
+	for _, o := range options {
+		if o.name == name || o.short == name {
+			return o, true
+		}
+	}
+	return option{}, false
+}
+
+// set changes a setting the way vim's :set does: "name" turns a toggle on,
+// "noname" off and "invname" or "name!" flips it, "name=value" sets any
+// other option and "name?" shows an option.
+func (s *settings) set(arg string) (string, error) {
+	if i := strings.IndexAny(arg, "=:"); i > 0 {
+		o, ok := findOption(arg[:i])
+		if !ok || o.toggle != nil {
+			return "", errors.New("unknown option: " + arg[:i])
+		}
+		if err := o.set(s, arg[i+1:]); err != nil {
+			return "", err
+		}
+		return s.show(o), nil
+	}
+	name, value := arg, true
+	switch {
+	case strings.HasSuffix(arg, "?"):
+		o, ok := findOption(strings.TrimSuffix(arg, "?"))
+		if !ok {
+			return "", errors.New("unknown option: " + arg)
+		}
+		return s.show(o), nil
+	case strings.HasSuffix(arg, "!"):
+		name = strings.TrimSuffix(arg, "!")
+		if o, ok := findOption(name); ok && o.toggle != nil {
+			value = !*o.toggle(s)
+		}
+	case strings.HasPrefix(arg, "inv"):
+		name = strings.TrimPrefix(arg, "inv")
+		if o, ok := findOption(name); ok && o.toggle != nil {
+			value = !*o.toggle(s)
+		}
+	case strings.HasPrefix(arg, "no"):
+		if _, ok := findOption(arg); !ok {
+			name, value = strings.TrimPrefix(arg, "no"), false
+		}
+	}
+	o, ok := findOption(name)
+	if !ok {
+		return "", errors.New("unknown option: " + arg)
+	}
+	if o.toggle == nil {
+		// like vim, naming an option with a value shows it
+		return s.show(o), nil
+	}
+	*o.toggle(s) = value
+	return s.show(o), nil
+}
+
+// show describes a setting as :set does, "name" for a toggle that is on,
+// "noname" for one that is off and "name=value" for the others.
+func (s *settings) show(o option) string {
+	if o.toggle == nil {
+		return o.name + "=" + o.get(s)
+	}
+	if *o.toggle(s) {
+		return o.name
+	}
+	return "no" + o.name
+}
+
+// cycle moves an option on to its next value, flipping a toggle.
+func (s *settings) cycle(o option) {
+	if o.toggle != nil {
+		*o.toggle(s) = !*o.toggle(s)
+		return
+	}
+	values := o.values()
+	next := 0
+	for i, v := range values {
+		if v == o.get(s) {
+			next = (i + 1) % len(values)
+		}
+	}
+	o.set(s, values[next])
+}
+
+// optionNames lists what :set can be given, for completion.
+func optionNames() []string {
+	names := []string{}
+	for _, o := range options {
+		if o.toggle == nil {
+			for _, v := range o.values() {
+				names = append(names, o.name+"="+v)
+			}
+			continue
+		}
+		names = append(names, o.name, "no"+o.name)
+	}
+	return names
+}
+
+type settingsMenu struct {
+	model  *model
+	cursor int
+	keys   keymap
+}
+
+func NewSettingsMenu(model *model) *settingsMenu {
+	return &settingsMenu{model, 0, model.keys.menu}
+}
+
+func (m *settingsMenu) update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, m.keys.Quit):
+			return m.model, tea.Quit
+		case key.Matches(msg, m.keys.Down):
+			if m.cursor >= len(options)-1 {
+				break
+			}
+			m.cursor += 1
+		case key.Matches(msg, m.keys.Up):
+			if m.cursor <= 0 {
+				break
+			}
+			m.cursor -= 1
+		case key.Matches(msg, m.keys.Select):
+			m.model.settings.cycle(options[m.cursor])
+		case key.Matches(msg, m.keys.Back):
+			m.model.current = m.model.mainMenu
+		}
+	}
+	return m.model, nil
+}
+
+func (m *settingsMenu) view() string {
+	b := strings.Builder{}
+	for i, o := range options {
+		if i == m.cursor {
+			b.WriteString("> ")
+		} else {
+			b.WriteString("  ")
+		}
+		switch {
+		case o.toggle == nil:
+			b.WriteString("[" + o.get(m.model.settings) + "] ")
+		case *o.toggle(m.model.settings):
+			b.WriteString("[x] ")
+		default:
+			b.WriteString("[ ] ")
+		}
+		b.WriteString(o.label)
+		b.WriteRune('\n')
+	}
+	b.WriteString("\nPress " + m.keys.Select.Help().Key + " to change a setting and '" + m.keys.Back.Help().Key + "' to go back to the main menu.\n")
+	return b.String()
+}
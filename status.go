@@ -0,0 +1,81 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/ethanefung/minesweeper/engine"
+)
+
+// The status line sits under the board like vim's statusline: the mode on
+// the left, then the command being typed, and what there is to know about
+// the game on the right.
+
+// vimMode names what the keys typed next will do.
+func (g *game) vimMode() string {
+	switch {
+	case g.command.active() || g.searchLine.active():
+		return "COMMAND"
+	case g.inMinimap:
+		return "MINIMAP"
+	case g.visual == charVisual:
+		return "VISUAL"
+	case g.visual == blockVisual:
+		return "VISUAL BLOCK"
+	case g.operator != "" || g.count != "" || g.pending != "":
+		return "PENDING"
+	}
+	return "NORMAL"
+}
+
+// revealedPercent is how much of the safe part of the board is revealed.
+func (g *game) revealedPercent() int {
+	safe := g.board.Width()*g.board.Height() - g.board.Config().Mines
+	revealed := 0
+	for i := 0; i < g.board.Width()*g.board.Height(); i++ {
+		if val, state := g.board.Cell(g.coord(i).Unwrap()); state == engine.Revealed && val != engine.Mine {
+			revealed++
+		}
+	}
+	if safe <= 0 {
+		return 100
+	}
+	return revealed * 100 / safe
+}
+
+// statusLine draws the status line as wide as the terminal, or the board
+// before the terminal has said how wide it is. The fields on the right
+// that do not fit are left out, the least useful first.
+func (g *game) statusLine() string {
+	t := g.model.settings.theme()
+	mode := g.vimMode()
+	left := t.modes[mode].Render(mode)
+	if typing := g.typing(); typing != "" {
+		left += t.status.Render(" " + typing)
+	}
+
+	// in the order they are dropped
+	fields := []string{
+		"seed " + strconv.FormatInt(g.board.Config().Seed, 10),
+		g.mode.String(),
+		strconv.Itoa(g.revealedPercent()) + "%",
+		"mines " + strconv.Itoa(g.board.FlagsLeft()),
+		strconv.Itoa(g.cursor.Y+1) + "," + strconv.Itoa(g.cursor.X+1),
+	}
+
+	width := g.model.width
+	if width == 0 {
+		width = g.boardWidth()
+	}
+	right := strings.Join(fields, "  ") + " "
+	for len(fields) > 1 && lipgloss.Width(left)+len(right)+1 > width {
+		fields = fields[1:]
+		right = strings.Join(fields, "  ") + " "
+	}
+	gap := width - lipgloss.Width(left) - len(right)
+	if gap < 1 {
+		gap = 1
+	}
+	return left + t.status.Render(strings.Repeat(" ", gap)+right)
+}
@@ -0,0 +1,426 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// palette is the colours of a theme, as a theme file in the themes folder
+// of the config directory gives them. A theme file starts from the built-in
+// theme named by base, dark when it has none, and changes the colours it
+// lists. Colours are hex ("#5f87af") or ANSI numbers ("170").
+type palette struct {
+	Base string `json:"base"`
+
+	Hidden string `json:"hidden"`
+	// Numbers colour the revealed numbers, 0 to 8
+	Numbers         []string `json:"numbers"`
+	Flag            string   `json:"flag"`
+	Mine            string   `json:"mine"`
+	Detonated       string   `json:"detonated"`
+	DetonatedText   string   `json:"detonatedText"`
+	DetonatedCursor string   `json:"detonatedCursor"`
+	WrongFlag       string   `json:"wrongFlag"`
+	Digits          string   `json:"digits"`
+
+	Cursor     string `json:"cursor"`
+	CursorText string `json:"cursorText"`
+	Selection  string `json:"selection"`
+	Match      string `json:"match"`
+	Pulse      string `json:"pulse"`
+
+	Gutter        string `json:"gutter"`
+	CurrentGutter string `json:"currentGutter"`
+	Status        string `json:"status"`
+	StatusText    string `json:"statusText"`
+	ModeText      string `json:"modeText"`
+	Normal        string `json:"normal"`
+	Pending       string `json:"pending"`
+	Visual        string `json:"visual"`
+	Command       string `json:"command"`
+
+	MenuSelected string `json:"menuSelected"`
+}
+
+var darkPalette = palette{
+	Hidden:          "#2e2e2e",
+	Numbers:         []string{"#000", "#00F", "#0F0", "#F00", "#800080", "", "#808080", "#800000", "#0FF"},
+	Flag:            "#ff5f5f",
+	Detonated:       "#F00",
+	DetonatedText:   "#000",
+	DetonatedCursor: "#900",
+	WrongFlag:       "#F00",
+	Digits:          "#F00",
+	Cursor:          "#696969",
+	Selection:       "#3a4a6a",
+	Match:           "#6a5a1a",
+	Pulse:           "#4f4f4f",
+	Gutter:          "#808080",
+	CurrentGutter:   "#d7af00",
+	Status:          "#3a3a3a",
+	StatusText:      "#d0d0d0",
+	ModeText:        "#1c1c1c",
+	Normal:          "#87afd7",
+	Pending:         "#af87d7",
+	Visual:          "#d7af5f",
+	Command:         "#87af87",
+	MenuSelected:    "170",
+}
+
+var lightPalette = palette{
+	Hidden:          "#bcbcbc",
+	Numbers:         []string{"#eeeeee", "#0000d7", "#008700", "#d70000", "#5f0087", "#800000", "#008787", "#000000", "#808080"},
+	Mine:            "#000000",
+	Flag:            "#d70000",
+	Detonated:       "#ff0000",
+	DetonatedText:   "#000000",
+	DetonatedCursor: "#af0000",
+	WrongFlag:       "#d70000",
+	Digits:          "#d70000",
+	Cursor:          "#8a8a8a",
+	Selection:       "#afd7ff",
+	Match:           "#ffd787",
+	Pulse:           "#dadada",
+	Gutter:          "#8a8a8a",
+	CurrentGutter:   "#af5f00",
+	Status:          "#d0d0d0",
+	StatusText:      "#303030",
+	ModeText:        "#ffffff",
+	Normal:          "#005f87",
+	Pending:         "#5f0087",
+	Visual:          "#af5f00",
+	Command:         "#005f00",
+	MenuSelected:    "128",
+}
+
+// the colour-blind palettes keep to the colours of Okabe and Ito, which
+// stay apart with every kind of colour blindness, and never tell numbers
+// apart by red and green alone
+var colourBlindDarkPalette = palette{
+	Hidden:          "#2e2e2e",
+	Numbers:         []string{"#000000", "#56B4E9", "#009E73", "#D55E00", "#CC79A7", "#E69F00", "#F0E442", "#ffffff", "#999999"},
+	Flag:            "#E69F00",
+	Detonated:       "#D55E00",
+	DetonatedText:   "#000000",
+	DetonatedCursor: "#E69F00",
+	WrongFlag:       "#E69F00",
+	Digits:          "#E69F00",
+	Cursor:          "#696969",
+	Selection:       "#0072B2",
+	Match:           "#6a5a1a",
+	Pulse:           "#4f4f4f",
+	Gutter:          "#808080",
+	CurrentGutter:   "#F0E442",
+	Status:          "#3a3a3a",
+	StatusText:      "#d0d0d0",
+	ModeText:        "#000000",
+	Normal:          "#56B4E9",
+	Pending:         "#CC79A7",
+	Visual:          "#E69F00",
+	Command:         "#009E73",
+	MenuSelected:    "#56B4E9",
+}
+
+var colourBlindLightPalette = palette{
+	Hidden:          "#bcbcbc",
+	Numbers:         []string{"#eeeeee", "#0072B2", "#009E73", "#D55E00", "#CC79A7", "#8a5a00", "#56B4E9", "#000000", "#808080"},
+	Mine:            "#000000",
+	Flag:            "#D55E00",
+	Detonated:       "#D55E00",
+	DetonatedText:   "#000000",
+	DetonatedCursor: "#E69F00",
+	WrongFlag:       "#D55E00",
+	Digits:          "#D55E00",
+	Cursor:          "#8a8a8a",
+	Selection:       "#a6d4f2",
+	Match:           "#F0E442",
+	Pulse:           "#dadada",
+	Gutter:          "#8a8a8a",
+	CurrentGutter:   "#0072B2",
+	Status:          "#d0d0d0",
+	StatusText:      "#303030",
+	ModeText:        "#ffffff",
+	Normal:          "#0072B2",
+	Pending:         "#CC79A7",
+	Visual:          "#D55E00",
+	Command:         "#009E73",
+	MenuSelected:    "#0072B2",
+}
+
+var highContrastPalette = palette{
+	Hidden:          "#5f5f5f",
+	Numbers:         []string{"#000000", "#5fafff", "#00ff00", "#ff5f5f", "#ff87ff", "#ffaf00", "#00ffff", "#ffffff", "#d0d0d0"},
+	Mine:            "#ffffff",
+	Flag:            "#ff0000",
+	Detonated:       "#ff0000",
+	DetonatedText:   "#ffffff",
+	DetonatedCursor: "#ffff00",
+	WrongFlag:       "#ff0000",
+	Digits:          "#ffff00",
+	Cursor:          "#ffff00",
+	CursorText:      "#000000",
+	Selection:       "#0000d7",
+	Match:           "#875f00",
+	Pulse:           "#8a8a8a",
+	Gutter:          "#d0d0d0",
+	CurrentGutter:   "#ffff00",
+	Status:          "#ffffff",
+	StatusText:      "#000000",
+	ModeText:        "#000000",
+	Normal:          "#00ffff",
+	Pending:         "#ff87ff",
+	Visual:          "#ffff00",
+	Command:         "#00ff00",
+	MenuSelected:    "#ffff00",
+}
+
+// theme owns every style the game and its menus draw with.
+type theme struct {
+	cell      lipgloss.Style
+	hidden    lipgloss.Style
+	flagged   lipgloss.Style
+	revealed  []lipgloss.Style
+	digits    lipgloss.Style
+	mine      lipgloss.Style
+	detonated lipgloss.Style
+	wrongFlag lipgloss.Style
+
+	gutter        lipgloss.Style
+	currentGutter lipgloss.Style
+	status        lipgloss.Style
+	mode          lipgloss.Style
+	modes         map[string]lipgloss.Style
+
+	menu         lipgloss.Style
+	item         lipgloss.Style
+	selectedItem lipgloss.Style
+
+	palette palette
+}
+
+func newTheme(p palette) *theme {
+	colour := func(s string) lipgloss.TerminalColor {
+		if s == "" {
+			return lipgloss.NoColor{}
+		}
+		return lipgloss.Color(s)
+	}
+	cell := lipgloss.NewStyle().Width(3).Height(1).Align(lipgloss.Center)
+	t := &theme{
+		cell:      cell,
+		hidden:    cell.Copy().Background(colour(p.Hidden)),
+		flagged:   cell.Copy().Foreground(colour(p.Flag)),
+		digits:    cell.Copy().Foreground(colour(p.Digits)),
+		mine:      cell.Copy().Foreground(colour(p.Mine)),
+		detonated: cell.Copy().Background(colour(p.Detonated)).Foreground(colour(p.DetonatedText)),
+		wrongFlag: cell.Copy().Background(colour(p.Hidden)).Foreground(colour(p.WrongFlag)),
+
+		gutter:        lipgloss.NewStyle().Foreground(colour(p.Gutter)),
+		currentGutter: lipgloss.NewStyle().Foreground(colour(p.CurrentGutter)).Bold(true),
+		status:        lipgloss.NewStyle().Background(colour(p.Status)).Foreground(colour(p.StatusText)),
+		mode:          lipgloss.NewStyle().Bold(true).Padding(0, 1).Foreground(colour(p.ModeText)),
+		modes:         map[string]lipgloss.Style{},
+
+		menu:         lipgloss.NewStyle().Margin(1, 2),
+		item:         lipgloss.NewStyle().PaddingLeft(4),
+		selectedItem: lipgloss.NewStyle().PaddingLeft(4).Foreground(colour(p.MenuSelected)),
+
+		palette: p,
+	}
+	for _, n := range p.Numbers {
+		t.revealed = append(t.revealed, cell.Copy().Foreground(colour(n)))
+	}
+	modes := map[string]string{
+		"NORMAL":       p.Normal,
+		"PENDING":      p.Pending,
+		"VISUAL":       p.Visual,
+		"VISUAL BLOCK": p.Visual,
+		"COMMAND":      p.Command,
+		"MINIMAP":      p.Normal,
+	}
+	for mode, c := range modes {
+		t.modes[mode] = t.mode.Copy().Background(colour(c))
+	}
+	return t
+}
+
+func (t *theme) focused(style lipgloss.Style) lipgloss.Style {
+	style = style.Copy().Background(lipgloss.Color(t.palette.Cursor))
+	if t.palette.CursorText != "" {
+		style = style.Foreground(lipgloss.Color(t.palette.CursorText))
+	}
+	return style
+}
+
+// focusedDetonated is the cursor on the mine that went off, which has to
+// stand out from the cursor anywhere else.
+func (t *theme) focusedDetonated(style lipgloss.Style) lipgloss.Style {
+	return style.Copy().Background(lipgloss.Color(t.palette.DetonatedCursor))
+}
+
+func (t *theme) selected(style lipgloss.Style) lipgloss.Style {
+	return style.Copy().Background(lipgloss.Color(t.palette.Selection))
+}
+
+func (t *theme) matched(style lipgloss.Style) lipgloss.Style {
+	return style.Copy().Background(lipgloss.Color(t.palette.Match))
+}
+
+func (t *theme) pulsing(style lipgloss.Style) lipgloss.Style {
+	return style.Copy().Background(lipgloss.Color(t.palette.Pulse))
+}
+
+// themes are the built-in themes and those in the config directory, by
+// name. autoTheme is the built-in theme that suits the terminal's
+// background.
+var themes = map[string]*theme{
+	"dark":          newTheme(darkPalette),
+	"light":         newTheme(lightPalette),
+	"high-contrast": newTheme(highContrastPalette),
+
+	"colourblind-dark":  newTheme(colourBlindDarkPalette),
+	"colourblind-light": newTheme(colourBlindLightPalette),
+}
+
+var autoTheme = "dark"
+
+const themesDir = "themes"
+
+// loadThemes adds the theme files in the config directory to the built-in
+// themes and works out which suits the terminal. It has to run before the
+// program starts, as asking the terminal for its background reads from it.
+// A theme file that cannot be read is left out and an error says why.
+func loadThemes() []error {
+	if !lipgloss.HasDarkBackground() {
+		autoTheme = "light"
+	}
+	dir, err := configPath(themesDir)
+	if err != nil {
+		return []error{fmt.Errorf("themes were not loaded: %w", err)}
+	}
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return []error{fmt.Errorf("themes were not loaded: %w", err)}
+	}
+	errs := []error{}
+	files := map[string][]byte{}
+	names := []string{}
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), ".json")
+		if entry.IsDir() || name == entry.Name() {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("theme %s was not loaded: %w", name, err))
+			continue
+		}
+		files[name] = data
+		names = append(names, name)
+	}
+	// a theme's base can be another theme file, so the files are only read
+	// once every one of them is known
+	loaded := map[string]*theme{}
+	for _, name := range names {
+		if _, err := loadTheme(name, files, loaded, map[string]bool{}); err != nil {
+			errs = append(errs, fmt.Errorf("theme %s was not loaded: %w", name, err))
+		}
+	}
+	for name, t := range loaded {
+		themes[name] = t
+	}
+	return errs
+}
+
+// loadTheme reads the theme file called name over its base, loading the
+// base first when it is a theme file too. A base names a built-in theme
+// when no other file has its name. visiting holds the files whose bases
+// are being loaded, to catch bases that go round in a circle.
+func loadTheme(name string, files map[string][]byte, loaded map[string]*theme, visiting map[string]bool) (*theme, error) {
+	if t, ok := loaded[name]; ok {
+		return t, nil
+	}
+	if visiting[name] {
+		return nil, fmt.Errorf("the bases go round in a circle back to %s", name)
+	}
+	visiting[name] = true
+	defer delete(visiting, name)
+
+	data := files[name]
+	var file struct {
+		Base string `json:"base"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	if file.Base == "" {
+		file.Base = "dark"
+	}
+	base, ok := themes[file.Base]
+	if _, isFile := files[file.Base]; isFile && file.Base != name {
+		t, err := loadTheme(file.Base, files, loaded, visiting)
+		if err != nil {
+			return nil, fmt.Errorf("base %s: %w", file.Base, err)
+		}
+		base, ok = t, true
+	}
+	if !ok {
+		return nil, fmt.Errorf("no theme is called %q", file.Base)
+	}
+	p, err := readPalette(data, base.palette)
+	if err != nil {
+		return nil, err
+	}
+	t := newTheme(p)
+	loaded[name] = t
+	return t, nil
+}
+
+// readPalette reads the colours of a theme file over a copy of its base's.
+func readPalette(data []byte, base palette) (palette, error) {
+	p := base
+	p.Numbers = append([]string(nil), p.Numbers...)
+	if err := json.Unmarshal(data, &p); err != nil {
+		return palette{}, err
+	}
+	if len(p.Numbers) != len(base.Numbers) {
+		return palette{}, fmt.Errorf("numbers needs a colour for each of 0 to 8")
+	}
+	return p, nil
+}
+
+// themeNames lists the themes the theme setting can be set to.
+func themeNames() []string {
+	names := []string{"auto"}
+	for name := range themes {
+		names = append(names, name)
+	}
+	sort.Strings(names[1:])
+	return names
+}
+
+// glyphs are the symbols drawn besides the numbers. The ASCII ones take a
+// column each in every terminal, where the width of emoji varies.
+type glyphs struct {
+	flag    string
+	playing string
+	won     string
+	lost    string
+}
+
+var emojiGlyphs = glyphs{flag: "🚩", playing: "🙂", won: "😎", lost: "😵"}
+
+// the faces keep to the two columns the emoji take
+var asciiGlyphs = glyphs{flag: "F", playing: ":)", won: "B)", lost: "X("}
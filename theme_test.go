@@ -0,0 +1,125 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoadTheme(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		// load is the theme loaded, err part of the error loading it and
+		// hidden and cursor the colours it ends up with
+		load   string
+		err    string
+		hidden string
+		cursor string
+	}{
+		{
+			name:   "no base",
+			files:  map[string]string{"mine": `{"cursor": "#123"}`},
+			load:   "mine",
+			hidden: darkPalette.Hidden,
+			cursor: "#123",
+		},
+		{
+			name:   "a built-in base",
+			files:  map[string]string{"mine": `{"base": "light"}`},
+			load:   "mine",
+			hidden: lightPalette.Hidden,
+			cursor: lightPalette.Cursor,
+		},
+		{
+			name: "a chain of bases",
+			files: map[string]string{
+				"a": `{"base": "b", "cursor": "#aaa"}`,
+				"b": `{"base": "c", "hidden": "#bbb", "cursor": "#bbb"}`,
+				"c": `{"base": "light"}`,
+			},
+			load:   "a",
+			hidden: "#bbb",
+			cursor: "#aaa",
+		},
+		{
+			name:   "a file named after its built-in base",
+			files:  map[string]string{"dark": `{"base": "dark", "cursor": "#123"}`},
+			load:   "dark",
+			hidden: darkPalette.Hidden,
+			cursor: "#123",
+		},
+		{
+			name: "bases in a circle",
+			files: map[string]string{
+				"a": `{"base": "b"}`,
+				"b": `{"base": "a"}`,
+			},
+			load: "a",
+			err:  "the bases go round in a circle back to a",
+		},
+		{
+			name:  "an unknown base",
+			files: map[string]string{"mine": `{"base": "nope"}`},
+			load:  "mine",
+			err:   `no theme is called "nope"`,
+		},
+		{
+			name:  "its own base",
+			files: map[string]string{"mine": `{"base": "mine"}`},
+			load:  "mine",
+			err:   `no theme is called "mine"`,
+		},
+		{
+			name: "a base that cannot be loaded",
+			files: map[string]string{
+				"a": `{"base": "b"}`,
+				"b": `{"numbers": ["#000"]}`,
+			},
+			load: "a",
+			err:  "base b: numbers needs a colour for each of 0 to 8",
+		},
+		{
+			name:  "too few numbers",
+			files: map[string]string{"mine": `{"numbers": ["#000", "#111"]}`},
+			load:  "mine",
+			err:   "numbers needs a colour for each of 0 to 8",
+		},
+		{
+			name:  "not json",
+			files: map[string]string{"mine": `{"cursor": `},
+			load:  "mine",
+			err:   "unexpected end of JSON input",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := map[string][]byte{}
+			for name, data := range tt.files {
+				files[name] = []byte(data)
+			}
+			theme, err := loadTheme(tt.load, files, map[string]*theme{}, map[string]bool{})
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("loadTheme returned %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if theme.palette.Hidden != tt.hidden || theme.palette.Cursor != tt.cursor {
+				t.Errorf("hidden and cursor are %q and %q, want %q and %q", theme.palette.Hidden, theme.palette.Cursor, tt.hidden, tt.cursor)
+			}
+		})
+	}
+}
+
+func TestReadPaletteCopiesNumbers(t *testing.T) {
+	p, err := readPalette([]byte(`{"numbers": ["#0", "#1", "#2", "#3", "#4", "#5", "#6", "#7", "#8"]}`), darkPalette)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Numbers[1] != "#1" || darkPalette.Numbers[1] == "#1" {
+		t.Errorf("numbers are %q and the dark theme's %q", p.Numbers, darkPalette.Numbers)
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"github.com/charmbracelet/lipgloss"
+	"github.com/ethanefung/minesweeper/engine"
+)
+
+// The board is drawn through a viewport, the rows and columns that fit in
+// the terminal, which follows the cursor and scrolls as vim's windows do.
+// Until the terminal has said how big it is the whole board is drawn.
+
+// reservedLines counts the lines view draws besides the board: the header
+// and the blank lines around it, the seed, the hint and the blank line
+// above it once a game is over, the status line and the command line. The
+// command line is counted even when it is empty, so that the board does not
+// scroll whenever a message comes and goes, as in vim. The blank line under
+// a classic board is its last row's.
+func (g *game) reservedLines() int {
+	lines := 3 + g.wrappedLines(g.seedLine()) + 1 + g.wrappedLines(g.commandLine())
+	if hint := g.hint(); hint != "" {
+		lines += 1 + g.wrappedLines(hint)
+	}
+	return lines
+}
+
+// wrappedLines is how many lines the terminal takes to show line.
+func (g *game) wrappedLines(line string) int {
+	width := g.model.width
+	if width == 0 || lipgloss.Width(line) <= width {
+		return 1
+	}
+	return (lipgloss.Width(line) + width - 1) / width
+}
+
+// cellWidth is how many columns a cell takes and rowHeight how many lines
+// a row of the board does, blank lines between the rows included.
+func (g *game) cellWidth() int {
+	d := g.model.settings.density()
+	if d.bordered {
+		return d.cell + 1
+	}
+	return d.cell
+}
+
+func (g *game) rowHeight() int {
+	d := g.model.settings.density()
+	if d.spaced || d.bordered {
+		return 2
+	}
+	return 1
+}
+
+// viewRows is how many rows of the board fit in the terminal.
+func (g *game) viewRows() int {
+	height := g.model.height
+	if height == 0 {
+		return g.board.Height()
+	}
+	height -= g.reservedLines()
+	if g.model.settings.columnLetters {
+		height--
+	}
+	if g.model.settings.density().bordered {
+		height--
+	}
+	return clamp(height/g.rowHeight(), 1, g.board.Height())
+}
+
+// viewColumns is how many columns of the board fit in the terminal.
+func (g *game) viewColumns() int {
+	width := g.model.width
+	if width == 0 {
+		return g.board.Width()
+	}
+	width -= g.gutterWidth() + g.minimapWidth()
+	if g.model.settings.density().bordered {
+		width--
+	}
+	return clamp(width/g.cellWidth(), 1, g.board.Width())
+}
+
+func clamp(v, low, high int) int {
+	if v > high {
+		v = high
+	}
+	if v < low {
+		v = low
+	}
+	return v
+}
+
+// follow scrolls the viewport as little as it takes to show the cursor,
+// keeping it on the board after the board or the terminal changed size.
+func (g *game) follow() {
+	rows, columns := g.viewRows(), g.viewColumns()
+	if g.cursor.Y < g.top {
+		g.top = g.cursor.Y
+	}
+	if g.cursor.Y >= g.top+rows {
+		g.top = g.cursor.Y - rows + 1
+	}
+	if g.cursor.X < g.left {
+		g.left = g.cursor.X
+	}
+	if g.cursor.X >= g.left+columns {
+		g.left = g.cursor.X - columns + 1
+	}
+	g.top = clamp(g.top, 0, g.board.Height()-rows)
+	g.left = clamp(g.left, 0, g.board.Width()-columns)
+}
+
+// visible reports whether c is inside the viewport.
+func (g *game) visible(c engine.Coord) bool {
+	return c.Y >= g.top && c.Y < g.top+g.viewRows() && c.X >= g.left && c.X < g.left+g.viewColumns()
+}
+
+// scroll moves the viewport n rows down, or up when n is negative, and
+// keeps the cursor in it the way ctrl+e and ctrl+y do.
+func (g *game) scroll(n int) {
+	rows := g.viewRows()
+	g.top = clamp(g.top+n, 0, g.board.Height()-rows)
+	g.cursor.Y = clamp(g.cursor.Y, g.top, g.top+rows-1)
+}
+
+// scrollHalf moves the viewport and the cursor n rows, half the viewport
+// when no count is given, as ctrl+d and ctrl+u do. The count is kept for
+// the next one, as in vim.
+func (g *game) scrollHalf(n int, counted bool, direction int) {
+	if counted {
+		g.scrollAmount = n
+	}
+	amount := g.scrollAmount
+	if amount == 0 {
+		amount = g.viewRows() / 2
+		if amount == 0 {
+			amount = 1
+		}
+	}
+	rows := g.viewRows()
+	g.top = clamp(g.top+direction*amount, 0, g.board.Height()-rows)
+	g.cursor.Y = clamp(g.cursor.Y+direction*amount, 0, g.board.Height()-1)
+}
+
+// updateScroll handles zz, zt and zb, which scroll the cursor's row to the
+// middle, top and bottom of the viewport, reporting whether key was one of
+// them.
+func (g *game) updateScroll(key string) bool {
+	rows := g.viewRows()
+	switch key {
+	case "zz":
+		g.top = g.cursor.Y - (rows-1)/2
+	case "zt":
+		g.top = g.cursor.Y
+	case "zb":
+		g.top = g.cursor.Y - rows + 1
+	default:
+		return false
+	}
+	g.top = clamp(g.top, 0, g.board.Height()-rows)
+	return true
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"errors"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/ethanefung/minesweeper/engine"
+)
+
+type visualMode int64
+
+const (
+	noVisual visualMode = iota
+	// charVisual selects cells in reading order, like v selects text
+	charVisual
+	// blockVisual selects a rectangle, like ctrl+v
+	blockVisual
+)
+
+// startVisual enters a visual mode with the selection anchored at the
+// cursor. Asking for the mode already on leaves it, as in vim.
+func (g *game) startVisual(mode visualMode) {
+	if g.visual == mode {
+		g.visual = noVisual
+		return
+	}
+	if g.visual == noVisual {
+		g.anchor = g.cursor
+	}
+	g.visual = mode
+}
+
+func (g *game) selected(c engine.Coord) bool {
+	switch g.visual {
+	case charVisual:
+		from, to := g.index(g.anchor), g.index(g.cursor)
+		if from > to {
+			from, to = to, from
+		}
+		return g.index(c) >= from && g.index(c) <= to
+	case blockVisual:
+		return between(c.X, g.anchor.X, g.cursor.X) && between(c.Y, g.anchor.Y, g.cursor.Y)
+	}
+	return false
+}
+
+func between(v, a, b int) bool {
+	if a > b {
+		a, b = b, a
+	}
+	return v >= a && v <= b
+}
+
+// selection lists the selected cells in reading order.
+func (g *game) selection() []engine.Coord {
+	cells := []engine.Coord{}
+	for i := 0; i < g.board.Width()*g.board.Height(); i++ {
+		if g.selected(g.coord(i)) {
+			cells = append(cells, g.coord(i))
+		}
+	}
+	return cells
+}
+
+// updateVisual handles the keys that mean something else in visual mode and
+// reports whether key was one of them.
+func (g *game) updateVisual(key string) (tea.Cmd, bool) {
+	switch key {
+	case "esc":
+		g.visual = noVisual
+	case "o":
+		g.anchor, g.cursor = g.cursor, g.anchor
+	case "f":
+		g.recordVisual(key)
+		return g.apply(g.selection(), g.flag), true
+	case "x":
+		g.recordVisual(key)
+		return g.apply(g.selection(), g.reveal), true
+	case "d":
+		g.recordVisual(key)
+		return g.apply(g.selection(), g.chord), true
+	default:
+		return nil, false
+	}
+	return nil, true
+}
+
+// recordVisual makes the visual change about to be made the one '.'
+// repeats.
+func (g *game) recordVisual(op string) {
+	g.typed = g.visualChange(op)
+	g.changing()
+}
+
+// apply makes a move on every cell given, stopping when the game ends. The
+// moves are undone together and visual mode is left.
+func (g *game) apply(cells []engine.Coord, move func(x, y int) error) tea.Cmd {
+	g.visual = noVisual
+	var err error
+	g.board.Batch(func() {
+		for _, c := range cells {
+			if g.board.Over() {
+				break
+			}
+			if e := move(c.Unwrap()); err == nil {
+				err = e
+			}
+		}
+	})
+	return g.move(err)
+}
+
+// flag flags a hidden cell and leaves a flagged one be.
+func (g *game) flag(x, y int) error {
+	if _, state := g.board.Cell(x, y); state != engine.Hidden {
+		return nil
+	}
+	return g.board.ToggleFlag(x, y)
+}
+
+// reveal reveals a hidden cell, leaving flagged and revealed ones be.
+func (g *game) reveal(x, y int) error {
+	if _, state := g.board.Cell(x, y); state != engine.Hidden {
+		return nil
+	}
+	return g.board.Reveal(x, y)
+}
+
+// chord chords a revealed number, the way the settings say to.
+func (g *game) chord(x, y int) error {
+	if val, state := g.board.Cell(x, y); state != engine.Revealed || val == 0 {
+		return nil
+	}
+	if g.model.settings.aggressiveChord {
+		return g.board.AggressiveChord(x, y)
+	}
+	if err := g.board.Chord(x, y); !errors.Is(err, engine.ErrChordFlags) {
+		return err
+	}
+	return nil
+}